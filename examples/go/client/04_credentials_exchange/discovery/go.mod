@@ -5,3 +5,5 @@ go 1.22
 replace github.com/joinself/self-go-sdk => ../../../..
 
 require github.com/joinself/self-go-sdk v0.0.0-00010101000000-000000000000
+
+require github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e // indirect
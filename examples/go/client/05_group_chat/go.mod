@@ -6,3 +6,5 @@ toolchain go1.24.2
 
 
 require github.com/joinself/self-go-sdk v0.0.0-00010101000000-000000000000
+
+require github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e // indirect
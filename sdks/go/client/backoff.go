@@ -0,0 +1,29 @@
+package client
+
+import (
+	"math/rand"
+	"time"
+)
+
+// backoff computes exponentially growing retry delays
+type backoff struct {
+	initial    time.Duration
+	max        time.Duration
+	multiplier float64
+	jitter     float64
+}
+
+// delay returns the wait before the given attempt, starting at 1
+func (b backoff) delay(attempt int) time.Duration {
+	delay := float64(b.initial)
+	for i := 1; i < attempt && delay < float64(b.max); i++ {
+		delay *= b.multiplier
+	}
+	if delay > float64(b.max) {
+		delay = float64(b.max)
+	}
+	if b.jitter > 0 {
+		delay += delay * b.jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,25 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	backoff := backoff{initial: time.Second, max: 5 * time.Second, multiplier: 2}
+
+	assert.Equal(t, time.Second, backoff.delay(1))
+	assert.Equal(t, 2*time.Second, backoff.delay(2))
+	assert.Equal(t, 4*time.Second, backoff.delay(3))
+	assert.Equal(t, 5*time.Second, backoff.delay(4))
+	assert.Equal(t, 5*time.Second, backoff.delay(100))
+
+	backoff.jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := backoff.delay(2)
+		assert.GreaterOrEqual(t, delay, time.Second)
+		assert.LessOrEqual(t, delay, 3*time.Second)
+	}
+}
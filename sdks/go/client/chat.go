@@ -1,9 +1,8 @@
 package client
 
 import (
-	"sync"
+	"context"
 
-	"github.com/joinself/self-go-sdk/event"
 	"github.com/joinself/self-go-sdk/keypair/signing"
 	"github.com/joinself/self-go-sdk/message"
 )
@@ -11,6 +10,7 @@ import (
 // ChatMessage represents a received chat message
 type ChatMessage struct {
 	from        string
+	fromName    string
 	text        string
 	id          string
 	refID       string
@@ -29,8 +29,7 @@ type Chat struct {
 	client *Client
 
 	// Event handlers
-	onMessageHandlers []func(ChatMessage)
-	mu                sync.RWMutex
+	onMessageHandlers handlerList[func(ChatMessage)]
 }
 
 // newChat creates a new chat component
@@ -41,19 +40,28 @@ func newChat(client *Client) *Chat {
 }
 
 // OnMessage registers a handler for incoming chat messages
-func (c *Chat) OnMessage(handler func(ChatMessage)) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.onMessageHandlers = append(c.onMessageHandlers, handler)
+func (c *Chat) OnMessage(handler func(ChatMessage)) Unsubscribe {
+	return c.onMessageHandlers.add(handler)
 }
 
 // Send sends a chat message to a peer
 func (c *Chat) Send(peerDID string, messageText string) error {
-	return c.SendWithAttachments(peerDID, messageText, nil)
+	return c.SendWithAttachmentsContext(context.Background(), peerDID, messageText, nil)
+}
+
+// SendContext sends a chat message to a peer, giving up when ctx is done.
+// A message abandoned this way may still be delivered.
+func (c *Chat) SendContext(ctx context.Context, peerDID string, messageText string) error {
+	return c.SendWithAttachmentsContext(ctx, peerDID, messageText, nil)
 }
 
 // SendWithAttachments sends a chat message with file attachments
 func (c *Chat) SendWithAttachments(peerDID string, messageText string, attachments []ChatAttachment) error {
+	return c.SendWithAttachmentsContext(context.Background(), peerDID, messageText, attachments)
+}
+
+// SendWithAttachmentsContext sends a chat message with file attachments, giving up when ctx is done
+func (c *Chat) SendWithAttachmentsContext(ctx context.Context, peerDID string, messageText string, attachments []ChatAttachment) error {
 	if c.client.isClosed() {
 		return ErrClientClosed
 	}
@@ -80,11 +88,16 @@ func (c *Chat) SendWithAttachments(peerDID string, messageText string, attachmen
 		return err
 	}
 
-	return c.client.sendMessage(peerAddress, *content)
+	return c.client.outbox.send(ctx, OutboxChat, peerAddress, *content)
 }
 
 // Reply sends a reply to a specific message
 func (c *Chat) Reply(originalMessage ChatMessage, replyText string) error {
+	return c.ReplyContext(context.Background(), originalMessage, replyText)
+}
+
+// ReplyContext sends a reply to a specific message, giving up when ctx is done
+func (c *Chat) ReplyContext(ctx context.Context, originalMessage ChatMessage, replyText string) error {
 	if c.client.isClosed() {
 		return ErrClientClosed
 	}
@@ -104,7 +117,7 @@ func (c *Chat) Reply(originalMessage ChatMessage, replyText string) error {
 		return err
 	}
 
-	return c.client.sendMessage(peerAddress, *content)
+	return c.client.outbox.send(ctx, OutboxChat, peerAddress, *content)
 }
 
 // From returns the sender's DID
@@ -112,6 +125,11 @@ func (m ChatMessage) From() string {
 	return m.from
 }
 
+// FromName returns the sender's display name from the contact book
+func (m ChatMessage) FromName() string {
+	return m.fromName
+}
+
 // Text returns the message text
 func (m ChatMessage) Text() string {
 	return m.text
@@ -161,7 +179,7 @@ func (c *Chat) onWelcome(from *signing.PublicKey, groupAddress *signing.PublicKe
 	// New connection established - no specific action needed
 }
 
-func (c *Chat) onKeyPackage(from *signing.PublicKey) {
+func (c *Chat) onKeyPackage(from *signing.PublicKey, groupAddress *signing.PublicKey) {
 	// Key package received - no specific action needed
 }
 
@@ -169,32 +187,33 @@ func (c *Chat) onIntroduction(from *signing.PublicKey, tokenCount int) {
 	// Introduction received - connection is now ready for chat
 }
 
-func (c *Chat) onChatMessage(msg *event.Message) {
+func (c *Chat) onChatMessage(msg InboundMessage) {
 	// Decode the chat message
 	chat, err := message.DecodeChat(msg.Content())
 	if err != nil {
+		c.client.reportMessageError(ComponentChat, "DecodeChat", msg, err)
 		return
 	}
 
 	// Create ChatMessage object
 	chatMessage := ChatMessage{
-		from:  msg.FromAddress().String(),
-		text:  chat.Message(),
-		id:    string(msg.ID()),
-		refID: string(chat.Referencing()),
+		from:     msg.FromAddress().String(),
+		fromName: c.client.contacts.DisplayName(msg.FromAddress().String()),
+		text:     chat.Message(),
+		id:       string(msg.ID()),
+		refID:    string(chat.Referencing()),
 		// TODO: Handle attachments
 		attachments: []ChatAttachment{},
 	}
 
 	// Notify handlers
-	c.mu.RLock()
-	handlers := make([]func(ChatMessage), len(c.onMessageHandlers))
-	copy(handlers, c.onMessageHandlers)
-	c.mu.RUnlock()
+	handlers := c.onMessageHandlers.snapshot()
 
 	for _, handler := range handlers {
-		go handler(chatMessage) // Run handlers in goroutines to avoid blocking
+		c.client.runHandler(ComponentChat, chatMessage.from, func() { handler(chatMessage) })
 	}
+
+	c.client.publish(Event{Type: EventChatMessage, PeerDID: chatMessage.from, ChatMessage: &chatMessage})
 }
 
 func (c *Chat) close() {
@@ -1,26 +1,59 @@
 package client
 
 import (
+	"context"
+	"encoding/hex"
+	"log/slog"
 	"sync"
 
 	"github.com/joinself/self-go-sdk/account"
-	"github.com/joinself/self-go-sdk/event"
+	"github.com/joinself/self-go-sdk/crypto"
 	"github.com/joinself/self-go-sdk/keypair/signing"
 	"github.com/joinself/self-go-sdk/message"
 )
 
 // Client provides a high-level interface to the Self SDK
 type Client struct {
-	account *account.Account
-	config  *Config
+	transport Transport
+	config    *Config
+	logger    *slog.Logger
 
 	// Internal state
 	inboxAddress *signing.PublicKey
 	closed       bool
+	done         chan struct{}
 	mu           sync.RWMutex
 
 	// Request tracking
-	requests sync.Map
+	requests *requestRegistry
+
+	// Recently received message IDs
+	seenMessages *messageWindow
+
+	// Custom content handlers
+	handlers  contentHandlers
+	handlerMu sync.RWMutex
+
+	// Message middleware
+	middleware   []Middleware
+	middlewareMu sync.RWMutex
+
+	// Handler dispatch
+	dispatcher *dispatcher
+
+	// Transport events held until New has initialized the client
+	started bool
+	held    []func()
+	startMu sync.Mutex
+
+	// Event streams
+	eventStreams handlerList[*eventStream]
+
+	// Connection state
+	state         ConnectionState
+	stateChanged  chan struct{}
+	stateHandlers handlerList[func(StateChange)]
+	stateMu       sync.Mutex
 
 	// Sub-components
 	discovery     *Discovery
@@ -29,8 +62,10 @@ type Client struct {
 	groupChats    *GroupChats
 	notifications *Notifications
 	storage       *Storage
+	contacts      *Contacts
 	pairing       *Pairing
 	connection    *Connection
+	outbox        *Outbox
 }
 
 // New creates a new Self client
@@ -40,32 +75,54 @@ func New(config Config) (*Client, error) {
 	}
 
 	client := &Client{
-		config: &config,
-	}
-
-	// Convert to account config and set up callbacks
-	accountConfig := config.toAccountConfig()
-	accountConfig.Callbacks = account.Callbacks{
-		OnConnect:    client.onConnect,
-		OnDisconnect: client.onDisconnect,
-		OnWelcome:    client.onWelcome,
-		OnKeyPackage: client.onKeyPackage,
-		OnMessage:    client.onMessage,
-	}
-
-	// Initialize account
-	acc, err := account.New(accountConfig)
+		config:       &config,
+		logger:       newLogger(&config),
+		done:         make(chan struct{}),
+		requests:     newRequestRegistry(),
+		seenMessages: newMessageWindow(config.Dedup.WindowSize),
+		stateChanged: make(chan struct{}),
+	}
+	client.dispatcher = newClientDispatcher(client)
+
+	// Set up callbacks and initialize the transport
+	callbacks := TransportCallbacks{
+		OnConnect: func() {
+			client.deliver(client.onConnect)
+		},
+		OnDisconnect: func(err error) {
+			client.deliver(func() { client.onDisconnect(err) })
+		},
+		OnWelcome: func(from, to *signing.PublicKey, welcome *crypto.Welcome) {
+			client.deliver(func() { client.onWelcome(from, to, welcome) })
+		},
+		OnKeyPackage: func(from, to *signing.PublicKey, keyPackage *crypto.KeyPackage) {
+			client.deliver(func() { client.onKeyPackage(from, to, keyPackage) })
+		},
+		OnMessage: func(msg InboundMessage) {
+			client.deliver(func() { client.onMessage(msg) })
+		},
+	}
+
+	factory := config.Transport
+	if factory == nil {
+		factory = newAccountTransport
+	}
+
+	transport, err := factory(&config, callbacks)
 	if err != nil {
+		client.dispatcher.close()
 		return nil, err
 	}
-	client.account = acc
+	client.transport = transport
 
 	// Open inbox
-	inboxAddress, err := acc.InboxOpen()
+	inboxAddress, err := transport.InboxOpen()
 	if err != nil {
+		client.dispatcher.close()
 		return nil, err
 	}
 	client.inboxAddress = inboxAddress
+	client.logger.Info("client started", "did", inboxAddress.String())
 
 	// Initialize sub-components
 	client.discovery = newDiscovery(client)
@@ -74,20 +131,70 @@ func New(config Config) (*Client, error) {
 	client.groupChats = newGroupChats(client)
 	client.notifications = newNotifications(client)
 	client.storage = newStorage(client)
+	client.contacts = newContacts(client)
 	client.pairing = newPairing(client)
 	client.connection = newConnection(client)
+	client.outbox = newOutbox(client)
+	client.loadSeenMessages()
+
+	go client.sweepRequests()
+	go client.flushSeenMessages()
+
+	client.start()
 
 	return client, nil
 }
 
+// deliver handles a transport event, holding it if the client is still starting.
+// Under DispatchBlock it first waits for room in the handler queue.
+func (c *Client) deliver(event func()) {
+	c.startMu.Lock()
+	if !c.started {
+		c.held = append(c.held, event)
+		c.startMu.Unlock()
+		return
+	}
+	c.startMu.Unlock()
+
+	c.dispatcher.wait()
+	event()
+}
+
+// start marks the client as started, handling held events in order. Events
+// delivered while held ones run are held too, so order is kept.
+func (c *Client) start() {
+	for {
+		c.startMu.Lock()
+		held := c.held
+		c.held = nil
+		if len(held) == 0 {
+			c.started = true
+			c.startMu.Unlock()
+			return
+		}
+		c.startMu.Unlock()
+
+		for _, event := range held {
+			event()
+		}
+	}
+}
+
 // DID returns the client's decentralized identifier
 func (c *Client) DID() string {
 	return c.inboxAddress.String()
 }
 
-// Account returns the underlying Self account
+// Account returns the underlying Self account, or nil when the client
+// runs on a custom transport
 func (c *Client) Account() *account.Account {
-	return c.account
+	acc, _ := c.transport.(*account.Account)
+	return acc
+}
+
+// Transport returns the transport the client runs on
+func (c *Client) Transport() Transport {
+	return c.transport
 }
 
 // Discovery returns the discovery component
@@ -120,6 +227,11 @@ func (c *Client) Storage() *Storage {
 	return c.storage
 }
 
+// Contacts returns the contact book component
+func (c *Client) Contacts() *Contacts {
+	return c.contacts
+}
+
 // Pairing returns the pairing component
 func (c *Client) Pairing() *Pairing {
 	return c.pairing
@@ -130,6 +242,11 @@ func (c *Client) Connection() *Connection {
 	return c.connection
 }
 
+// Outbox returns the durable outbox component
+func (c *Client) Outbox() *Outbox {
+	return c.outbox
+}
+
 // Close closes the client and releases resources
 func (c *Client) Close() error {
 	c.mu.Lock()
@@ -140,6 +257,18 @@ func (c *Client) Close() error {
 	}
 
 	c.closed = true
+	c.setState(StateClosed, nil)
+	close(c.done)
+	c.logger.Info("client closed")
+
+	// Release anyone waiting for a response
+	c.requests.close(ErrClientClosed)
+
+	// Stop accepting events; handlers already queued still run
+	c.dispatcher.close()
+
+	// Keep the message IDs seen since the last flush
+	c.persistSeenMessages()
 
 	// Close sub-components
 	if c.discovery != nil {
@@ -160,12 +289,18 @@ func (c *Client) Close() error {
 	if c.storage != nil {
 		c.storage.close()
 	}
+	if c.contacts != nil {
+		c.contacts.close()
+	}
 	if c.pairing != nil {
 		c.pairing.close()
 	}
 	if c.connection != nil {
 		c.connection.close()
 	}
+	if c.outbox != nil {
+		c.outbox.close()
+	}
 
 	// Note: The account doesn't have a close method in the current SDK
 	// This might need to be added to the underlying SDK
@@ -175,7 +310,10 @@ func (c *Client) Close() error {
 
 // Internal methods for handling account callbacks
 
-func (c *Client) onConnect(account *account.Account) {
+func (c *Client) onConnect() {
+	c.logger.Info("connected", logKeyComponent, ComponentClient)
+	c.setState(StateConnected, nil)
+
 	// Connection established - notify sub-components
 	if c.discovery != nil {
 		c.discovery.onConnect()
@@ -195,15 +333,26 @@ func (c *Client) onConnect(account *account.Account) {
 	if c.storage != nil {
 		c.storage.onConnect()
 	}
+	if c.contacts != nil {
+		c.contacts.onConnect()
+	}
 	if c.pairing != nil {
 		c.pairing.onConnect()
 	}
 	if c.connection != nil {
 		c.connection.onConnect()
 	}
+	if c.outbox != nil {
+		c.outbox.onConnect()
+	}
+
+	c.publish(Event{Type: EventConnected})
 }
 
-func (c *Client) onDisconnect(account *account.Account, err error) {
+func (c *Client) onDisconnect(err error) {
+	c.logger.Warn("disconnected", logKeyComponent, ComponentClient, "error", err)
+	c.setState(StateDisconnected, err)
+
 	// Connection lost - notify sub-components
 	if c.discovery != nil {
 		c.discovery.onDisconnect(err)
@@ -223,90 +372,178 @@ func (c *Client) onDisconnect(account *account.Account, err error) {
 	if c.storage != nil {
 		c.storage.onDisconnect(err)
 	}
+	if c.contacts != nil {
+		c.contacts.onDisconnect(err)
+	}
 	if c.pairing != nil {
 		c.pairing.onDisconnect(err)
 	}
 	if c.connection != nil {
 		c.connection.onDisconnect(err)
 	}
+
+	c.publish(Event{Type: EventDisconnected, Cause: err})
 }
 
-func (c *Client) onWelcome(account *account.Account, wlc *event.Welcome) {
+func (c *Client) onWelcome(from, to *signing.PublicKey, welcome *crypto.Welcome) {
+	if !c.admitConnection(from.String()) {
+		return
+	}
+
 	// Accept the connection automatically
-	groupAddress, err := account.ConnectionAccept(
-		wlc.ToAddress(),
-		wlc.Welcome(),
-	)
+	groupAddress, err := c.transport.ConnectionAccept(to, welcome)
 	if err != nil {
-		// Log error but don't fail - this is handled internally
+		c.reportError(ErrorEvent{
+			Component: ComponentClient,
+			Operation: "ConnectionAccept",
+			PeerDID:   from.String(),
+			Err:       err,
+		})
 		return
 	}
 
+	c.logger.Info("connection accepted",
+		logKeyComponent, ComponentClient,
+		logKeyPeer, from.String(),
+		"group", groupAddress.String(),
+	)
+
 	// Notify sub-components of new connection
 	if c.discovery != nil {
-		c.discovery.onWelcome(wlc.FromAddress(), groupAddress)
+		c.discovery.onWelcome(from, groupAddress)
 	}
 	if c.chat != nil {
-		c.chat.onWelcome(wlc.FromAddress(), groupAddress)
+		c.chat.onWelcome(from, groupAddress)
 	}
 	if c.credentials != nil {
-		c.credentials.onWelcome(wlc.FromAddress(), groupAddress)
+		c.credentials.onWelcome(from, groupAddress)
 	}
 	if c.groupChats != nil {
-		c.groupChats.onWelcome(wlc.FromAddress(), groupAddress)
+		c.groupChats.onWelcome(from, groupAddress)
 	}
 	if c.notifications != nil {
-		c.notifications.onWelcome(wlc.FromAddress(), groupAddress)
+		c.notifications.onWelcome(from, groupAddress)
 	}
 	if c.storage != nil {
-		c.storage.onWelcome(wlc.FromAddress(), groupAddress)
+		c.storage.onWelcome(from, groupAddress)
+	}
+	if c.contacts != nil {
+		c.contacts.onWelcome(from, groupAddress)
 	}
 	if c.pairing != nil {
-		c.pairing.onWelcome(wlc.FromAddress(), groupAddress)
+		c.pairing.onWelcome(from, groupAddress)
 	}
 	if c.connection != nil {
-		c.connection.onWelcome(wlc.FromAddress(), groupAddress)
+		c.connection.onWelcome(from, groupAddress)
 	}
 }
 
-func (c *Client) onKeyPackage(account *account.Account, kp *event.KeyPackage) {
+func (c *Client) onKeyPackage(from, to *signing.PublicKey, keyPackage *crypto.KeyPackage) {
+	if !c.admitConnection(from.String()) {
+		return
+	}
+
 	// Establish connection automatically
-	_, err := account.ConnectionEstablish(kp.ToAddress(), kp.KeyPackage())
+	groupAddress, err := c.transport.ConnectionEstablish(to, keyPackage)
 	if err != nil {
-		// Log error but don't fail - this is handled internally
+		c.reportError(ErrorEvent{
+			Component: ComponentClient,
+			Operation: "ConnectionEstablish",
+			PeerDID:   from.String(),
+			Err:       err,
+		})
 		return
 	}
 
+	c.logger.Info("connection established",
+		logKeyComponent, ComponentClient,
+		logKeyPeer, from.String(),
+		"group", groupAddress.String(),
+	)
+
 	// Notify sub-components
 	if c.discovery != nil {
-		c.discovery.onKeyPackage(kp.FromAddress())
+		c.discovery.onKeyPackage(from, groupAddress)
 	}
 	if c.chat != nil {
-		c.chat.onKeyPackage(kp.FromAddress())
+		c.chat.onKeyPackage(from, groupAddress)
 	}
 	if c.credentials != nil {
-		c.credentials.onKeyPackage(kp.FromAddress())
+		c.credentials.onKeyPackage(from, groupAddress)
 	}
 	if c.groupChats != nil {
-		c.groupChats.onKeyPackage(kp.FromAddress())
+		c.groupChats.onKeyPackage(from, groupAddress)
 	}
 	if c.notifications != nil {
-		c.notifications.onKeyPackage(kp.FromAddress())
+		c.notifications.onKeyPackage(from, groupAddress)
 	}
 	if c.storage != nil {
-		c.storage.onKeyPackage(kp.FromAddress())
+		c.storage.onKeyPackage(from, groupAddress)
+	}
+	if c.contacts != nil {
+		c.contacts.onKeyPackage(from, groupAddress)
 	}
 	if c.pairing != nil {
-		c.pairing.onKeyPackage(kp.FromAddress())
+		c.pairing.onKeyPackage(from, groupAddress)
 	}
 	if c.connection != nil {
-		c.connection.onKeyPackage(kp.FromAddress())
+		c.connection.onKeyPackage(from, groupAddress)
+	}
+}
+
+func (c *Client) onMessage(msg InboundMessage) {
+	if c.isDuplicate(msg) {
+		c.logger.Debug("duplicate message dropped",
+			logKeyComponent, ComponentClient,
+			logKeyPeer, msg.FromAddress().String(),
+			logKeyMessageID, hex.EncodeToString(msg.ID()),
+		)
+		return
+	}
+
+	mc := &MessageContext{
+		Context:     context.Background(),
+		Direction:   Inbound,
+		ContentType: msg.Content().ContentType(),
+		PeerDID:     msg.FromAddress().String(),
+		Content:     msg.Content(),
+		Message:     msg,
+	}
+
+	err := c.runMiddleware(mc, func(mc *MessageContext) error {
+		// Only messages that pass middleware and the policy lists count as
+		// interactions, and only with peers already in the contact book
+		peerDID := msg.FromAddress().String()
+		if c.contacts != nil && c.config.ConnectionPolicy.checkLists(peerDID) == nil {
+			c.contacts.seen(peerDID)
+		}
+
+		if mc.Content != msg.Content() {
+			c.routeMessage(&rewrittenMessage{InboundMessage: msg, content: mc.Content})
+		} else {
+			c.routeMessage(msg)
+		}
+		return nil
+	})
+	if err != nil {
+		// Rejected messages are dropped
+		c.reportMessageError(ComponentClient, "Middleware", msg, err)
 	}
 }
 
-func (c *Client) onMessage(account *account.Account, msg *event.Message) {
+func (c *Client) routeMessage(msg InboundMessage) {
 	// Route messages to appropriate handlers based on content type
-	switch event.ContentTypeOf(msg) {
+	contentType := msg.Content().ContentType()
+	routed := true
+
+	c.logger.Debug("message received",
+		logKeyComponent, ComponentClient,
+		logKeyPeer, msg.FromAddress().String(),
+		logKeyMessageID, hex.EncodeToString(msg.ID()),
+		logKeyContentType, contentType,
+	)
+
+	switch contentType {
 	case message.ContentTypeDiscoveryResponse:
 		if c.discovery != nil {
 			c.discovery.onDiscoveryResponse(msg)
@@ -345,60 +582,98 @@ func (c *Client) onMessage(account *account.Account, msg *event.Message) {
 	case message.ContentTypeIntroduction:
 		// Handle introduction messages - these establish tokens for communication
 		c.handleIntroduction(msg)
+	case message.ContentTypeCustom:
+		if c.connection != nil && c.connection.onDisconnectNotice(msg) {
+			// Disconnect notices are handled by the client itself
+			return
+		}
+		// Other custom content is left to registered content handlers
+		routed = false
 	default:
-		// Unknown message type - could be handled by extensions in the future
+		// Unknown message type - left to registered content handlers
+		routed = false
+		c.logger.Debug("message not routed to a component",
+			logKeyComponent, ComponentClient,
+			logKeyPeer, msg.FromAddress().String(),
+			logKeyContentType, contentType,
+		)
 	}
+
+	c.dispatchContentHandlers(contentType, msg, routed)
 }
 
-func (c *Client) handleIntroduction(msg *event.Message) {
+func (c *Client) handleIntroduction(msg InboundMessage) {
+	if !c.admitIntroduction(msg.FromAddress().String()) {
+		return
+	}
+
 	introduction, err := message.DecodeIntroduction(msg.Content())
 	if err != nil {
+		c.reportMessageError(ComponentClient, "DecodeIntroduction", msg, err)
 		return
 	}
 
 	tokens, err := introduction.Tokens()
 	if err != nil {
+		c.reportMessageError(ComponentClient, "IntroductionTokens", msg, err)
 		return
 	}
 
 	// Store tokens for future communication
+	stored := 0
 	for _, token := range tokens {
-		err = c.account.TokenStore(
+		err = c.transport.TokenStore(
 			msg.FromAddress(),
 			msg.ToAddress(),
 			msg.ToAddress(),
 			token,
 		)
 		if err != nil {
-			// Log error but continue with other tokens
+			// Report error but continue with other tokens
+			c.reportMessageError(ComponentClient, "TokenStore", msg, err)
 			continue
 		}
+		stored++
+	}
+
+	c.logger.Debug("introduction received",
+		logKeyComponent, ComponentClient,
+		logKeyPeer, msg.FromAddress().String(),
+		"tokens", stored,
+	)
+
+	if stored == 0 && len(tokens) > 0 {
+		// None of the tokens could be stored, so the peer cannot be messaged
+		return
 	}
 
 	// Notify sub-components of introduction
 	if c.discovery != nil {
-		c.discovery.onIntroduction(msg.FromAddress(), len(tokens))
+		c.discovery.onIntroduction(msg.FromAddress(), stored)
 	}
 	if c.chat != nil {
-		c.chat.onIntroduction(msg.FromAddress(), len(tokens))
+		c.chat.onIntroduction(msg.FromAddress(), stored)
 	}
 	if c.credentials != nil {
-		c.credentials.onIntroduction(msg.FromAddress(), len(tokens))
+		c.credentials.onIntroduction(msg.FromAddress(), stored)
 	}
 	if c.groupChats != nil {
-		c.groupChats.onIntroduction(msg.FromAddress(), len(tokens))
+		c.groupChats.onIntroduction(msg.FromAddress(), stored)
 	}
 	if c.notifications != nil {
-		c.notifications.onIntroduction(msg.FromAddress(), len(tokens))
+		c.notifications.onIntroduction(msg.FromAddress(), stored)
 	}
 	if c.storage != nil {
-		c.storage.onIntroduction(msg.FromAddress(), len(tokens))
+		c.storage.onIntroduction(msg.FromAddress(), stored)
+	}
+	if c.contacts != nil {
+		c.contacts.onIntroduction(msg.FromAddress(), stored)
 	}
 	if c.pairing != nil {
-		c.pairing.onIntroduction(msg.FromAddress(), len(tokens))
+		c.pairing.onIntroduction(msg.FromAddress(), stored)
 	}
 	if c.connection != nil {
-		c.connection.onIntroduction(msg.FromAddress(), len(tokens))
+		c.connection.onIntroduction(msg.FromAddress(), msg.ToAddress(), stored)
 	}
 }
 
@@ -410,17 +685,59 @@ func (c *Client) isClosed() bool {
 	return c.closed
 }
 
-func (c *Client) storeRequest(requestID string, completer interface{}) {
-	c.requests.Store(requestID, completer)
+// decodeRequestID converts a hex request ID back to the message ID it was derived from
+func decodeRequestID(requestID string) []byte {
+	id, err := hex.DecodeString(requestID)
+	if err != nil {
+		return []byte(requestID)
+	}
+	return id
+}
+
+func (c *Client) sendMessage(to *signing.PublicKey, content message.Content) error {
+	return c.sendMessageContext(context.Background(), to, content)
 }
 
-func (c *Client) loadAndDeleteRequest(requestID string) (interface{}, bool) {
-	return c.requests.LoadAndDelete(requestID)
+func (c *Client) sendMessageContext(ctx context.Context, to *signing.PublicKey, content message.Content) error {
+	return c.sendMessageOr(ctx, to, content, nil)
 }
 
-func (c *Client) sendMessage(to *signing.PublicKey, content message.Content) error {
+// sendMessageOr sends content through the middleware chain. When the transport
+// fails to send it and fallback is set, fallback decides the result.
+// A send abandoned because ctx is done may still be delivered.
+func (c *Client) sendMessageOr(ctx context.Context, to *signing.PublicKey, content message.Content, fallback func(mc *MessageContext, err error) error) error {
 	if c.isClosed() {
 		return ErrClientClosed
 	}
-	return c.account.MessageSend(to, &content)
+
+	mc := &MessageContext{
+		Context:     ctx,
+		Direction:   Outbound,
+		ContentType: content.ContentType(),
+		PeerDID:     to.String(),
+		Content:     &content,
+	}
+
+	return c.runMiddleware(mc, func(mc *MessageContext) error {
+		err := runContext(mc.Context, func() error {
+			return c.transport.MessageSend(to, mc.Content)
+		})
+		if err != nil {
+			c.logger.Warn("message send failed",
+				logKeyPeer, mc.PeerDID,
+				logKeyContentType, mc.ContentType,
+				"error", err,
+			)
+			if fallback != nil {
+				return fallback(mc, err)
+			}
+			return err
+		}
+		c.logger.Debug("message sent",
+			logKeyPeer, mc.PeerDID,
+			logKeyMessageID, hex.EncodeToString(mc.Content.ID()),
+			logKeyContentType, mc.ContentType,
+		)
+		return nil
+	})
 }
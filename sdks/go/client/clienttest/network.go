@@ -0,0 +1,283 @@
+// Package clienttest provides an in-memory network for exercising clients
+// end to end without a connection to the Self relay.
+package clienttest
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joinself/academy/sdks/go/client"
+	"github.com/joinself/self-go-sdk/crypto"
+	"github.com/joinself/self-go-sdk/keypair/signing"
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/joinself/self-go-sdk/object"
+)
+
+var (
+	ErrNetworkClosed         = errors.New("network is closed")
+	ErrPeerNotFound          = errors.New("peer not found on network")
+	ErrPeerOffline           = errors.New("peer is offline")
+	ErrValueNotFound         = errors.New("value not found")
+	ErrObjectNotFound        = errors.New("object not found")
+	ErrUnknownKeyPackage     = errors.New("unknown key package")
+	ErrUnknownWelcome        = errors.New("unknown welcome")
+	ErrIssuingNotSupported   = errors.New("credential issuance is not supported by the in-memory network")
+	ErrOutOfBandNotSupported = errors.New("out-of-band key packages are not supported by the in-memory network")
+)
+
+// Network is an in-process virtual network. Every client created from it
+// runs on an in-memory transport: messages, connection handshakes,
+// introductions and object uploads are routed between clients without
+// touching the Self infrastructure. Key packages and welcomes are opaque
+// handles that only the network itself reads; they never reach the SDK.
+type Network struct {
+	nodes       map[string]*node
+	keyPackages map[*crypto.KeyPackage]*signing.PublicKey
+	welcomes    map[*crypto.Welcome]*pendingWelcome
+	groups      map[string][2]*signing.PublicKey
+	objects     map[string]*object.Object
+	waiters     map[string][]chan struct{}
+	closed      bool
+	mu          sync.Mutex
+}
+
+// pendingWelcome tracks a welcome until the negotiating peer accepts it
+type pendingWelcome struct {
+	from         *signing.PublicKey
+	to           *signing.PublicKey
+	groupAddress *signing.PublicKey
+}
+
+// NewNetwork creates an empty in-memory network
+func NewNetwork() *Network {
+	return &Network{
+		nodes:       make(map[string]*node),
+		keyPackages: make(map[*crypto.KeyPackage]*signing.PublicKey),
+		welcomes:    make(map[*crypto.Welcome]*pendingWelcome),
+		groups:      make(map[string][2]*signing.PublicKey),
+		objects:     make(map[string]*object.Object),
+		waiters:     make(map[string][]chan struct{}),
+	}
+}
+
+// NewClient creates a client attached to the network
+func (n *Network) NewClient() (*client.Client, error) {
+	return n.NewClientWithConfig(client.Config{})
+}
+
+// NewClientWithConfig creates a client attached to the network using the
+// given configuration. The transport is always replaced by the network's.
+func (n *Network) NewClientWithConfig(config client.Config) (*client.Client, error) {
+	n.mu.Lock()
+	closed := n.closed
+	n.mu.Unlock()
+	if closed {
+		return nil, ErrNetworkClosed
+	}
+
+	config.Transport = func(_ *client.Config, callbacks client.TransportCallbacks) (client.Transport, error) {
+		return newNode(n, callbacks), nil
+	}
+
+	return client.New(config)
+}
+
+// Connect negotiates a connection from a to b and waits until both sides
+// have received each other's introductions
+func (n *Network) Connect(ctx context.Context, a, b *client.Client) error {
+	aAddress := signing.FromAddress(a.DID())
+	bAddress := signing.FromAddress(b.DID())
+	if aAddress == nil || bAddress == nil {
+		return client.ErrInvalidPeerDID
+	}
+
+	done := make(chan struct{})
+	key := pairKey(a.DID(), b.DID())
+
+	n.mu.Lock()
+	n.waiters[key] = append(n.waiters[key], done)
+	n.mu.Unlock()
+
+	err := a.Transport().ConnectionNegotiate(aAddress, bAddress, time.Now().Add(time.Minute))
+	if err != nil {
+		n.removeWaiter(key, done)
+		return err
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		n.removeWaiter(key, done)
+		return ctx.Err()
+	}
+}
+
+// Disconnect takes a client offline. The client is notified through its
+// disconnect callback and sends fail until Reconnect is called.
+func (n *Network) Disconnect(c *client.Client, cause error) error {
+	nd, err := n.lookup(c.DID())
+	if err != nil {
+		return err
+	}
+	nd.setOnline(false, cause)
+	return nil
+}
+
+// Reconnect brings a client back online and notifies its connect callback
+func (n *Network) Reconnect(c *client.Client) error {
+	nd, err := n.lookup(c.DID())
+	if err != nil {
+		return err
+	}
+	nd.setOnline(true, nil)
+	return nil
+}
+
+// Deliver injects content into a client's inbox as if it was sent by from.
+// It is useful for simulating redelivery or messages from unknown peers.
+func (n *Network) Deliver(from *signing.PublicKey, to *client.Client, content *message.Content) error {
+	nd, err := n.lookup(to.DID())
+	if err != nil {
+		return err
+	}
+	nd.deliverMessage(from, content)
+	return nil
+}
+
+// Notifications returns the number of push notifications sent to a client
+func (n *Network) Notifications(c *client.Client) int {
+	nd, err := n.lookup(c.DID())
+	if err != nil {
+		return 0
+	}
+	nd.mu.Lock()
+	defer nd.mu.Unlock()
+	return nd.notifications
+}
+
+// Close shuts the network down and stops delivering events
+func (n *Network) Close() {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return
+	}
+	n.closed = true
+	nodes := make([]*node, 0, len(n.nodes))
+	for _, nd := range n.nodes {
+		nodes = append(nodes, nd)
+	}
+	n.mu.Unlock()
+
+	for _, nd := range nodes {
+		nd.stop()
+	}
+}
+
+// register attaches a node to the network under its inbox address
+func (n *Network) register(nd *node) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return ErrNetworkClosed
+	}
+	n.nodes[nd.address.String()] = nd
+	return nil
+}
+
+// lookup finds the node for an address
+func (n *Network) lookup(did string) (*node, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return nil, ErrNetworkClosed
+	}
+	nd, ok := n.nodes[did]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPeerNotFound, did)
+	}
+	return nd, nil
+}
+
+// route finds the node for a message's recipient. Messages sent to a group
+// address are delivered to the group member other than the sender.
+func (n *Network) route(from, to *signing.PublicKey) (*node, error) {
+	n.mu.Lock()
+	members, ok := n.groups[to.String()]
+	n.mu.Unlock()
+	if ok {
+		to = members[0]
+		if to.String() == from.String() {
+			to = members[1]
+		}
+	}
+	return n.lookup(to.String())
+}
+
+// introduce delivers introductions in both directions and releases any
+// Connect calls waiting on the pair
+func (n *Network) introduce(a, b *node) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	a.deliverIntroduction(b.address, wg.Done)
+	b.deliverIntroduction(a.address, wg.Done)
+
+	go func() {
+		wg.Wait()
+
+		key := pairKey(a.address.String(), b.address.String())
+
+		n.mu.Lock()
+		waiters := n.waiters[key]
+		delete(n.waiters, key)
+		n.mu.Unlock()
+
+		for _, done := range waiters {
+			close(done)
+		}
+	}()
+}
+
+// removeWaiter drops a Connect call that gave up before the pair was introduced
+func (n *Network) removeWaiter(key string, done chan struct{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	waiters := n.waiters[key]
+	for i, waiter := range waiters {
+		if waiter == done {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) == 0 {
+		delete(n.waiters, key)
+		return
+	}
+	n.waiters[key] = waiters
+}
+
+// newAddress generates a random signing address
+func newAddress() *signing.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(fmt.Sprintf("clienttest: failed to generate key: %v", err))
+	}
+	return signing.FromAddress("00" + hex.EncodeToString(pub))
+}
+
+// pairKey returns an order-independent key for a pair of DIDs
+func pairKey(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	return a + "|" + b
+}
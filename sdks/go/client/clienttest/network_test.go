@@ -0,0 +1,300 @@
+package clienttest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/joinself/academy/sdks/go/client"
+	"github.com/joinself/self-go-sdk/keypair/signing"
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newConnectedPair creates two clients on a fresh network and connects them
+func newConnectedPair(t *testing.T) (*Network, *client.Client, *client.Client) {
+	network := NewNetwork()
+	t.Cleanup(network.Close)
+
+	alice, err := network.NewClient()
+	require.NoError(t, err)
+	t.Cleanup(func() { alice.Close() })
+
+	bob, err := network.NewClient()
+	require.NoError(t, err)
+	t.Cleanup(func() { bob.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, network.Connect(ctx, alice, bob))
+
+	return network, alice, bob
+}
+
+func TestNetworkChat(t *testing.T) {
+	_, alice, bob := newConnectedPair(t)
+
+	received := make(chan client.ChatMessage, 1)
+	bob.Chat().OnMessage(func(msg client.ChatMessage) {
+		received <- msg
+	})
+
+	require.NoError(t, alice.Chat().Send(bob.DID(), "hello bob"))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, alice.DID(), msg.From())
+		assert.Equal(t, "hello bob", msg.Text())
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for chat message")
+	}
+}
+
+func TestNetworkCredentialRequest(t *testing.T) {
+	_, alice, bob := newConnectedPair(t)
+
+	bob.Credentials().OnPresentationRequest(func(req *client.IncomingCredentialRequest) {
+		req.Reject()
+	})
+
+	details := []*client.CredentialDetail{
+		{
+			CredentialType: []string{"VerifiableCredential", "EmailCredential"},
+		},
+	}
+
+	req, err := alice.Credentials().RequestPresentation(bob.DID(), details)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := req.WaitForResponse(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, bob.DID(), resp.From())
+	assert.Equal(t, message.ResponseStatusForbidden, resp.Status())
+}
+
+func TestNetworkConnectionTracking(t *testing.T) {
+	_, alice, bob := newConnectedPair(t)
+
+	assert.True(t, alice.Connection().IsConnectedTo(bob.DID()))
+	assert.True(t, bob.Connection().IsConnectedTo(alice.DID()))
+
+	aliceSide, err := alice.Connection().GetConnection(bob.DID())
+	require.NoError(t, err)
+	bobSide, err := bob.Connection().GetConnection(alice.DID())
+	require.NoError(t, err)
+	assert.Equal(t, aliceSide.GroupAddress, bobSide.GroupAddress)
+}
+
+func TestNetworkConnectClients(t *testing.T) {
+	network := NewNetwork()
+	defer network.Close()
+
+	alice, err := network.NewClient()
+	require.NoError(t, err)
+	defer alice.Close()
+
+	bob, err := network.NewClient()
+	require.NoError(t, err)
+	defer bob.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.ConnectClients(ctx, alice, bob)
+	require.NoError(t, err)
+	assert.True(t, result.AToB.Connected())
+	assert.True(t, result.BToA.Connected())
+	assert.Equal(t, bob.DID(), result.AToB.Peer.DID)
+	assert.Equal(t, alice.DID(), result.BToA.Peer.DID)
+
+	// Both sides can message each other straight away
+	require.NoError(t, alice.Chat().Send(bob.DID(), "hello bob"))
+	require.NoError(t, bob.Chat().Send(alice.DID(), "hello alice"))
+}
+
+func TestNetworkConnectToPeer(t *testing.T) {
+	network := NewNetwork()
+	defer network.Close()
+
+	alice, err := network.NewClient()
+	require.NoError(t, err)
+	defer alice.Close()
+
+	bob, err := network.NewClient()
+	require.NoError(t, err)
+	defer bob.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := alice.Connection().ConnectToPeerContext(ctx, bob.DID())
+	require.NoError(t, err)
+	require.NoError(t, result.Error)
+	assert.True(t, result.Connected)
+	require.NoError(t, alice.Chat().Send(bob.DID(), "hello bob"))
+}
+
+func TestNetworkDisconnectPeer(t *testing.T) {
+	_, alice, bob := newConnectedPair(t)
+
+	disconnected := make(chan client.PeerInfo, 1)
+	bob.Connection().OnPeerDisconnected(func(peer client.PeerInfo) {
+		disconnected <- peer
+	})
+	chats := make(chan client.ChatMessage, 1)
+	bob.Chat().OnMessage(func(msg client.ChatMessage) {
+		chats <- msg
+	})
+	custom := make(chan client.InboundMessage, 1)
+	bob.RegisterContentHandler(message.ContentTypeCustom, func(msg client.InboundMessage) {
+		custom <- msg
+	})
+
+	require.NoError(t, alice.Connection().Disconnect(bob.DID(), true))
+	assert.False(t, alice.Connection().IsConnectedTo(bob.DID()))
+
+	// The peer forgets the connection without seeing the notice as chat
+	select {
+	case peer := <-disconnected:
+		assert.Equal(t, alice.DID(), peer.DID)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for peer disconnected")
+	}
+	assert.False(t, bob.Connection().IsConnectedTo(alice.DID()))
+	assert.Empty(t, chats)
+	assert.Empty(t, custom)
+}
+
+func TestNetworkDisconnect(t *testing.T) {
+	network, alice, bob := newConnectedPair(t)
+
+	received := make(chan client.ChatMessage, 1)
+	bob.Chat().OnMessage(func(msg client.ChatMessage) {
+		received <- msg
+	})
+
+	// An offline client cannot send
+	require.NoError(t, network.Disconnect(alice, nil))
+	assert.ErrorIs(t, alice.Chat().Send(bob.DID(), "lost"), ErrPeerOffline)
+	require.NoError(t, network.Reconnect(alice))
+
+	// Messages to an offline client are held until it reconnects
+	require.NoError(t, network.Disconnect(bob, nil))
+	require.NoError(t, alice.Chat().Send(bob.DID(), "held"))
+
+	select {
+	case <-received:
+		t.Fatal("message delivered to offline client")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, network.Reconnect(bob))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "held", msg.Text())
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for held message")
+	}
+}
+
+func TestNetworkConnectionState(t *testing.T) {
+	network := NewNetwork()
+	defer network.Close()
+
+	alice, err := network.NewClient()
+	require.NoError(t, err)
+	defer alice.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, alice.WaitUntilConnected(ctx))
+
+	// The client follows the network's view of its connection
+	require.NoError(t, network.Disconnect(alice, nil))
+	assert.Eventually(t, func() bool {
+		return alice.State() == client.StateDisconnected
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, network.Reconnect(alice))
+	require.NoError(t, alice.WaitUntilConnected(ctx))
+}
+
+func TestNetworkUnknownPeer(t *testing.T) {
+	network := NewNetwork()
+	defer network.Close()
+
+	alice, err := network.NewClient()
+	require.NoError(t, err)
+	defer alice.Close()
+
+	stranger := newAddress()
+	err = alice.Transport().ConnectionNegotiate(signing.FromAddress(alice.DID()), stranger, time.Now().Add(time.Minute))
+	assert.ErrorIs(t, err, ErrPeerNotFound)
+}
+
+func TestNetworkConnectFailure(t *testing.T) {
+	network := NewNetwork()
+	defer network.Close()
+
+	alice, err := network.NewClient()
+	require.NoError(t, err)
+	defer alice.Close()
+
+	bob, err := network.NewClient()
+	require.NoError(t, err)
+	defer bob.Close()
+
+	require.NoError(t, network.Disconnect(alice, nil))
+	err = network.Connect(context.Background(), alice, bob)
+	assert.ErrorIs(t, err, ErrPeerOffline)
+
+	// Failed and cancelled calls stop waiting for the pair
+	require.NoError(t, network.Reconnect(alice))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	network.Connect(ctx, alice, bob)
+
+	network.mu.Lock()
+	defer network.mu.Unlock()
+	assert.Empty(t, network.waiters)
+}
+
+func TestNetworkKeyPackageSingleUse(t *testing.T) {
+	network := NewNetwork()
+	defer network.Close()
+
+	alice, err := network.NewClient()
+	require.NoError(t, err)
+	defer alice.Close()
+
+	bob, err := network.NewClient()
+	require.NoError(t, err)
+	defer bob.Close()
+
+	aliceNode, err := network.lookup(alice.DID())
+	require.NoError(t, err)
+	keyPackage := aliceNode.newKeyPackage(signing.FromAddress(alice.DID()))
+
+	bobAddress := signing.FromAddress(bob.DID())
+	_, err = bob.Transport().ConnectionEstablish(bobAddress, keyPackage)
+	require.NoError(t, err)
+	_, err = bob.Transport().ConnectionEstablish(bobAddress, keyPackage)
+	assert.ErrorIs(t, err, ErrUnknownKeyPackage)
+}
+
+func TestNetworkOutOfBandNotSupported(t *testing.T) {
+	network := NewNetwork()
+	defer network.Close()
+
+	alice, err := network.NewClient()
+	require.NoError(t, err)
+	defer alice.Close()
+
+	_, err = alice.Discovery().GenerateQR()
+	assert.ErrorIs(t, err, ErrOutOfBandNotSupported)
+}
@@ -0,0 +1,484 @@
+package clienttest
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/joinself/academy/sdks/go/client"
+	"github.com/joinself/self-go-sdk/credential"
+	"github.com/joinself/self-go-sdk/crypto"
+	"github.com/joinself/self-go-sdk/keypair/signing"
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/joinself/self-go-sdk/object"
+	"github.com/joinself/self-go-sdk/token"
+)
+
+// node is the in-memory transport of a single client. Events for the client
+// are queued and delivered in order on the node's own goroutine, the same
+// way the Self account delivers its callbacks.
+type node struct {
+	network   *Network
+	address   *signing.PublicKey
+	callbacks client.TransportCallbacks
+
+	values        map[string]storedValue
+	online        bool
+	held          []func()
+	notifications int
+
+	queue   []func()
+	signal  chan struct{}
+	quit    chan struct{}
+	stopped bool
+	mu      sync.Mutex
+}
+
+// storedValue is a value in the node's key-value store
+type storedValue struct {
+	data    []byte
+	expires time.Time
+}
+
+// inboundMessage is a message delivered by the in-memory network
+type inboundMessage struct {
+	from    *signing.PublicKey
+	to      *signing.PublicKey
+	content *message.Content
+}
+
+// Ensure node satisfies the client transport interfaces
+var (
+	_ client.Transport      = (*node)(nil)
+	_ client.InboundMessage = (*inboundMessage)(nil)
+)
+
+// newNode creates a node and starts its delivery loop
+func newNode(network *Network, callbacks client.TransportCallbacks) *node {
+	nd := &node{
+		network:   network,
+		callbacks: callbacks,
+		values:    make(map[string]storedValue),
+		online:    true,
+		signal:    make(chan struct{}, 1),
+		quit:      make(chan struct{}),
+	}
+	go nd.run()
+	return nd
+}
+
+// InboxOpen generates the node's address, attaches it to the network and
+// reports the connection to the client
+func (nd *node) InboxOpen() (*signing.PublicKey, error) {
+	nd.mu.Lock()
+	if nd.address == nil {
+		nd.address = newAddress()
+	}
+	address := nd.address
+	nd.mu.Unlock()
+
+	if err := nd.network.register(nd); err != nil {
+		return nil, err
+	}
+
+	nd.deliver(func() {
+		if nd.callbacks.OnConnect != nil {
+			nd.callbacks.OnConnect()
+		}
+	})
+	return address, nil
+}
+
+// MessageSend routes content to the recipient's node, or to the other
+// member of a group
+func (nd *node) MessageSend(to *signing.PublicKey, content *message.Content) error {
+	if err := nd.checkOnline(); err != nil {
+		return err
+	}
+
+	target, err := nd.network.route(nd.address, to)
+	if err != nil {
+		return err
+	}
+
+	target.deliverMessage(nd.address, content)
+	return nil
+}
+
+// ConnectionNegotiate sends a key package to the peer
+func (nd *node) ConnectionNegotiate(asAddress, withAddress *signing.PublicKey, expires time.Time) error {
+	if err := nd.checkOnline(); err != nil {
+		return err
+	}
+
+	target, err := nd.network.lookup(withAddress.String())
+	if err != nil {
+		return err
+	}
+
+	keyPackage := nd.newKeyPackage(asAddress)
+	target.deliver(func() {
+		if target.callbacks.OnKeyPackage != nil {
+			target.callbacks.OnKeyPackage(asAddress, withAddress, keyPackage)
+		}
+	})
+	return nil
+}
+
+// ConnectionNegotiateOutOfBand is not supported, as the key package would be
+// embedded in a discovery request by the SDK, which cannot read the
+// network's handles
+func (nd *node) ConnectionNegotiateOutOfBand(asAddress *signing.PublicKey, expires time.Time) (*crypto.KeyPackage, error) {
+	return nil, ErrOutOfBandNotSupported
+}
+
+// ConnectionEstablish answers a key package with a welcome. Like the relay,
+// the network accepts each key package only once.
+func (nd *node) ConnectionEstablish(asAddress *signing.PublicKey, keyPackage *crypto.KeyPackage) (*signing.PublicKey, error) {
+	nd.network.mu.Lock()
+	from, ok := nd.network.keyPackages[keyPackage]
+	delete(nd.network.keyPackages, keyPackage)
+	nd.network.mu.Unlock()
+	if !ok {
+		return nil, ErrUnknownKeyPackage
+	}
+
+	target, err := nd.network.lookup(from.String())
+	if err != nil {
+		return nil, err
+	}
+
+	groupAddress := newAddress()
+	welcome := new(crypto.Welcome)
+
+	nd.network.mu.Lock()
+	nd.network.welcomes[welcome] = &pendingWelcome{
+		from:         asAddress,
+		to:           from,
+		groupAddress: groupAddress,
+	}
+	nd.network.groups[groupAddress.String()] = [2]*signing.PublicKey{asAddress, from}
+	nd.network.mu.Unlock()
+
+	target.deliver(func() {
+		if target.callbacks.OnWelcome != nil {
+			target.callbacks.OnWelcome(asAddress, from, welcome)
+		}
+	})
+
+	return groupAddress, nil
+}
+
+// ConnectionAccept accepts a welcome and exchanges introductions
+func (nd *node) ConnectionAccept(asAddress *signing.PublicKey, welcome *crypto.Welcome) (*signing.PublicKey, error) {
+	nd.network.mu.Lock()
+	pending, ok := nd.network.welcomes[welcome]
+	delete(nd.network.welcomes, welcome)
+	nd.network.mu.Unlock()
+	if !ok {
+		return nil, ErrUnknownWelcome
+	}
+
+	peer, err := nd.network.lookup(pending.from.String())
+	if err != nil {
+		return nil, err
+	}
+
+	nd.network.introduce(nd, peer)
+
+	return pending.groupAddress, nil
+}
+
+// TokenStore accepts tokens; the in-memory network does not require them
+func (nd *node) TokenStore(fromAddress, toAddress, forAddress *signing.PublicKey, tkn *token.Token) error {
+	return nil
+}
+
+// TokenRemove removes tokens; the in-memory network does not require them
+func (nd *node) TokenRemove(fromAddress, toAddress *signing.PublicKey) error {
+	return nil
+}
+
+// ValueStore stores a value in the node's key-value store
+func (nd *node) ValueStore(key string, value []byte) error {
+	return nd.ValueStoreWithExpiry(key, value, time.Time{})
+}
+
+// ValueStoreWithExpiry stores a value that disappears after it expires
+func (nd *node) ValueStoreWithExpiry(key string, value []byte, expires time.Time) error {
+	data := make([]byte, len(value))
+	copy(data, value)
+
+	nd.mu.Lock()
+	defer nd.mu.Unlock()
+	nd.values[key] = storedValue{data: data, expires: expires}
+	return nil
+}
+
+// ValueLookup retrieves a value from the node's key-value store
+func (nd *node) ValueLookup(key string) ([]byte, error) {
+	nd.mu.Lock()
+	defer nd.mu.Unlock()
+
+	value, ok := nd.values[key]
+	if !ok {
+		return nil, ErrValueNotFound
+	}
+	if !value.expires.IsZero() && time.Now().After(value.expires) {
+		delete(nd.values, key)
+		return nil, ErrValueNotFound
+	}
+
+	data := make([]byte, len(value.data))
+	copy(data, value.data)
+	return data, nil
+}
+
+// ValueRemove removes a value from the node's key-value store
+func (nd *node) ValueRemove(key string) error {
+	nd.mu.Lock()
+	defer nd.mu.Unlock()
+	delete(nd.values, key)
+	return nil
+}
+
+// ObjectUpload stores an object in the network's shared object store
+func (nd *node) ObjectUpload(obj *object.Object, persistLocal bool) error {
+	if err := nd.checkOnline(); err != nil {
+		return err
+	}
+
+	nd.network.mu.Lock()
+	defer nd.network.mu.Unlock()
+	nd.network.objects[hex.EncodeToString(obj.Id())] = obj
+	return nil
+}
+
+// ObjectDownload checks the object was uploaded to the network
+func (nd *node) ObjectDownload(obj *object.Object) error {
+	if err := nd.checkOnline(); err != nil {
+		return err
+	}
+
+	nd.network.mu.Lock()
+	defer nd.network.mu.Unlock()
+	if _, ok := nd.network.objects[hex.EncodeToString(obj.Id())]; !ok {
+		return ErrObjectNotFound
+	}
+	return nil
+}
+
+// CredentialIssue is not supported, as signing requires a real account
+func (nd *node) CredentialIssue(unsignedCredential *credential.Credential) (*credential.VerifiableCredential, error) {
+	return nil, ErrIssuingNotSupported
+}
+
+// PresentationIssue is not supported, as signing requires a real account
+func (nd *node) PresentationIssue(unsignedPresentation *credential.Presentation) (*credential.VerifiablePresentation, error) {
+	return nil, ErrIssuingNotSupported
+}
+
+// NotificationSend records a push notification for the recipient
+func (nd *node) NotificationSend(to *signing.PublicKey, summary *message.ContentSummary) error {
+	if err := nd.checkOnline(); err != nil {
+		return err
+	}
+
+	target, err := nd.network.lookup(to.String())
+	if err != nil {
+		return err
+	}
+
+	target.mu.Lock()
+	target.notifications++
+	target.mu.Unlock()
+	return nil
+}
+
+// SDKPairingCode returns a pairing code derived from the node's address
+func (nd *node) SDKPairingCode() (string, bool, error) {
+	nd.mu.Lock()
+	address := nd.address.String()
+	nd.mu.Unlock()
+	if len(address) > 8 {
+		address = address[:8]
+	}
+	return "clienttest-" + address, true, nil
+}
+
+// ID returns the message ID, which matches the content ID
+func (m *inboundMessage) ID() []byte {
+	return m.content.ID()
+}
+
+// FromAddress returns the sender's address
+func (m *inboundMessage) FromAddress() *signing.PublicKey {
+	return m.from
+}
+
+// ToAddress returns the recipient's address
+func (m *inboundMessage) ToAddress() *signing.PublicKey {
+	return m.to
+}
+
+// Content returns the message content
+func (m *inboundMessage) Content() *message.Content {
+	return m.content
+}
+
+// Internal helpers
+
+// newKeyPackage creates a key package handle and records who it belongs to
+func (nd *node) newKeyPackage(asAddress *signing.PublicKey) *crypto.KeyPackage {
+	keyPackage := new(crypto.KeyPackage)
+
+	nd.network.mu.Lock()
+	nd.network.keyPackages[keyPackage] = asAddress
+	nd.network.mu.Unlock()
+
+	return keyPackage
+}
+
+// deliverMessage queues a message for the node's message callback
+func (nd *node) deliverMessage(from *signing.PublicKey, content *message.Content) {
+	msg := &inboundMessage{
+		from:    from,
+		to:      nd.address,
+		content: content,
+	}
+
+	nd.deliver(func() {
+		if nd.callbacks.OnMessage != nil {
+			nd.callbacks.OnMessage(msg)
+		}
+	})
+}
+
+// deliverIntroduction queues an introduction from a peer and calls done once handled
+func (nd *node) deliverIntroduction(from *signing.PublicKey, done func()) {
+	content, err := message.NewIntroduction().
+		DocumentAddress(from).
+		Finish()
+	if err != nil {
+		done()
+		return
+	}
+
+	msg := &inboundMessage{
+		from:    from,
+		to:      nd.address,
+		content: content,
+	}
+
+	nd.deliver(func() {
+		defer done()
+		if nd.callbacks.OnMessage != nil {
+			nd.callbacks.OnMessage(msg)
+		}
+	})
+}
+
+// deliver queues an event, holding it while the node is offline
+func (nd *node) deliver(fn func()) {
+	nd.mu.Lock()
+	if nd.stopped {
+		nd.mu.Unlock()
+		return
+	}
+	if !nd.online {
+		nd.held = append(nd.held, fn)
+		nd.mu.Unlock()
+		return
+	}
+	nd.queue = append(nd.queue, fn)
+	nd.mu.Unlock()
+
+	nd.wake()
+}
+
+// setOnline changes the node's connectivity and notifies the client
+func (nd *node) setOnline(online bool, cause error) {
+	nd.mu.Lock()
+	if nd.stopped || nd.online == online {
+		nd.mu.Unlock()
+		return
+	}
+	nd.online = online
+
+	if online {
+		nd.queue = append(nd.queue, func() {
+			if nd.callbacks.OnConnect != nil {
+				nd.callbacks.OnConnect()
+			}
+		})
+		nd.queue = append(nd.queue, nd.held...)
+		nd.held = nil
+	} else {
+		nd.queue = append(nd.queue, func() {
+			if nd.callbacks.OnDisconnect != nil {
+				nd.callbacks.OnDisconnect(cause)
+			}
+		})
+	}
+	nd.mu.Unlock()
+
+	nd.wake()
+}
+
+// checkOnline returns an error if the node is offline
+func (nd *node) checkOnline() error {
+	nd.mu.Lock()
+	defer nd.mu.Unlock()
+	if nd.stopped {
+		return ErrNetworkClosed
+	}
+	if !nd.online {
+		return ErrPeerOffline
+	}
+	return nil
+}
+
+// wake signals the delivery loop that events are queued
+func (nd *node) wake() {
+	select {
+	case nd.signal <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers queued events in order until the node is stopped
+func (nd *node) run() {
+	for {
+		select {
+		case <-nd.quit:
+			return
+		case <-nd.signal:
+		}
+
+		for {
+			nd.mu.Lock()
+			if nd.stopped || len(nd.queue) == 0 {
+				nd.mu.Unlock()
+				break
+			}
+			fn := nd.queue[0]
+			nd.queue = nd.queue[1:]
+			nd.mu.Unlock()
+
+			fn()
+		}
+	}
+}
+
+// stop halts event delivery and drops queued events
+func (nd *node) stop() {
+	nd.mu.Lock()
+	defer nd.mu.Unlock()
+	if nd.stopped {
+		return
+	}
+	nd.stopped = true
+	nd.queue = nil
+	nd.held = nil
+	close(nd.quit)
+}
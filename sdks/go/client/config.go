@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"fmt"
+	"log/slog"
 	"os"
 	"time"
 
@@ -48,10 +49,38 @@ type Config struct {
 
 	// SkipSetup skips the setup phase during initialization
 	SkipSetup bool
+
+	// Transport creates the backend the client runs on (default: Self account).
+	// Storage settings are only required for the default transport.
+	Transport TransportFactory
+
+	// Logger receives structured logs from the client (default: discard).
+	// LogLevel only controls the verbosity of the native SDK.
+	Logger *slog.Logger
+
+	// Dispatch controls the worker pool that runs event handlers
+	Dispatch DispatchConfig
+
+	// Outbox persists and retries outbound content that fails to send
+	Outbox OutboxConfig
+
+	// Dedup controls how redelivered inbound messages are dropped
+	Dedup DedupConfig
+
+	// ConnectionPolicy decides which peers may connect (default: accept all)
+	ConnectionPolicy ConnectionPolicy
+
+	// TrustedIssuers are the DIDs of the credential issuers whose claims are
+	// recorded on contacts. When empty, claims from any issuer other than the
+	// subject itself are recorded.
+	TrustedIssuers []string
 }
 
 // validate checks if the configuration is valid
 func (c *Config) validate() error {
+	if c.Transport != nil {
+		return nil
+	}
 	if len(c.StorageKey) == 0 {
 		return ErrStorageKeyRequired
 	}
@@ -72,6 +101,8 @@ func (c *Config) toAccountConfig() *account.Config {
 
 	// Set environment
 	switch c.Environment {
+	case Production:
+		cfg.Environment = account.TargetProduction
 	default:
 		cfg.Environment = account.TargetSandbox
 	}
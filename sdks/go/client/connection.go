@@ -2,15 +2,52 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/joinself/self-go-sdk/keypair/signing"
+	"github.com/joinself/self-go-sdk/message"
 )
 
+// connectionsStorageKey is where tracked connections are persisted in encrypted storage
+const connectionsStorageKey = "self-client:connections"
+
+// outOfBandIntroductionTimeout bounds how long an answered discovery request
+// waits for the requester's introduction
+const outOfBandIntroductionTimeout = time.Hour
+
+// disconnectNoticeType identifies the custom content sent to a peer that is
+// being disconnected
+const disconnectNoticeType = "self-client/disconnect"
+
+// customPayload is the payload of custom content exchanged between clients
+type customPayload struct {
+	Type string `json:"type"`
+}
+
 // Connection handles direct peer-to-peer connections
 type Connection struct {
 	client *Client
+
+	// Connections with peers, by peer DID
+	connections map[string]*PeerConnection
+
+	// Expiry of connections established out of band, by group address,
+	// whose peer is only known once its introduction arrives
+	outOfBand map[string]time.Time
+
+	// Channels waiting for a peer to become usable, by peer DID
+	readyWaiters map[string][]chan PeerInfo
+	mu           sync.RWMutex
+
+	// Event handlers
+	onRejectedHandlers         handlerList[func(ConnectionRejection)]
+	onPeerConnectedHandlers    handlerList[func(PeerInfo)]
+	onPeerDisconnectedHandlers handlerList[func(PeerInfo)]
+	onPeerRemovedHandlers      handlerList[func(PeerInfo)]
 }
 
 // ConnectionResult represents the result of a connection attempt
@@ -20,11 +57,60 @@ type ConnectionResult struct {
 	Error     error
 }
 
-// newConnection creates a new connection component
+// PeerConnection describes an established connection with a peer
+type PeerConnection struct {
+	// PeerDID is the connected peer
+	PeerDID string
+
+	// GroupAddress is the address of the encrypted group shared with the peer
+	GroupAddress string
+
+	// EstablishedAt is when the connection was established
+	EstablishedAt time.Time
+
+	// TokensReceived is the number of tokens the peer sent in introductions
+	TokensReceived int
+
+	// LastIntroductionAt is when the last introduction from the peer arrived
+	LastIntroductionAt time.Time
+}
+
+// PeerInfo describes a peer in connection lifecycle events
+type PeerInfo struct {
+	// DID is the peer's DID
+	DID string
+
+	// GroupAddress is the address of the encrypted group shared with the peer
+	GroupAddress string
+
+	// EstablishedAt is when the connection was established
+	EstablishedAt time.Time
+
+	// TokensReceived is the number of tokens the peer sent in introductions
+	TokensReceived int
+}
+
+// info returns the lifecycle event view of a connection
+func (pc *PeerConnection) info() PeerInfo {
+	return PeerInfo{
+		DID:            pc.PeerDID,
+		GroupAddress:   pc.GroupAddress,
+		EstablishedAt:  pc.EstablishedAt,
+		TokensReceived: pc.TokensReceived,
+	}
+}
+
+// newConnection creates a new connection component, restoring tracked
+// connections from storage
 func newConnection(client *Client) *Connection {
-	return &Connection{
-		client: client,
+	c := &Connection{
+		client:       client,
+		connections:  make(map[string]*PeerConnection),
+		outOfBand:    make(map[string]time.Time),
+		readyWaiters: make(map[string][]chan PeerInfo),
 	}
+	c.load()
+	return c
 }
 
 // ConnectToPeer establishes a direct connection to another peer programmatically
@@ -35,6 +121,16 @@ func (c *Connection) ConnectToPeer(peerDID string) (*ConnectionResult, error) {
 
 // ConnectToPeerWithTimeout establishes a connection with a custom timeout
 func (c *Connection) ConnectToPeerWithTimeout(peerDID string, timeout time.Duration) (*ConnectionResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return c.ConnectToPeerContext(ctx, peerDID)
+}
+
+// ConnectToPeerContext establishes a connection, waiting until the peer's
+// introduction is stored and it can be messaged, or ctx is done. Without a
+// ctx deadline the negotiation expires after 30 seconds.
+func (c *Connection) ConnectToPeerContext(ctx context.Context, peerDID string) (*ConnectionResult, error) {
 	if c.client.isClosed() {
 		return nil, ErrClientClosed
 	}
@@ -59,29 +155,18 @@ func (c *Connection) ConnectToPeerWithTimeout(peerDID string, timeout time.Durat
 		}, nil
 	}
 
-	// Set up a channel to track connection establishment
-	connectionEstablished := make(chan bool, 1)
-	connectionError := make(chan error, 1)
-
-	// Set up temporary handlers to track the connection
-	originalOnWelcome := c.client.discovery.onResponseHandlers
-	c.client.discovery.OnResponse(func(peer *Peer) {
-		if peer.DID() == peerDID {
-			connectionEstablished <- true
-		}
-	})
-
-	// Restore original handlers after connection attempt
-	defer func() {
-		c.client.discovery.onResponseHandlers = originalOnWelcome
-	}()
+	// Watch for the peer before negotiating so its introduction is not missed
+	ready, unsubscribe := c.watchReady(peerDID)
+	defer unsubscribe()
 
 	// Initiate the connection negotiation
-	err := c.client.account.ConnectionNegotiate(
-		ourAddress,
-		peerAddress,
-		time.Now().Add(timeout),
-	)
+	err := runContext(ctx, func() error {
+		return c.client.transport.ConnectionNegotiate(
+			ourAddress,
+			peerAddress,
+			expiresFrom(ctx, 30*time.Second),
+		)
+	})
 	if err != nil {
 		return &ConnectionResult{
 			PeerDID:   peerDID,
@@ -90,112 +175,466 @@ func (c *Connection) ConnectToPeerWithTimeout(peerDID string, timeout time.Durat
 		}, nil
 	}
 
-	// Wait for connection establishment or timeout
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
+	// Wait until the peer can be messaged or ctx is done
 	select {
-	case <-connectionEstablished:
+	case <-ready:
 		return &ConnectionResult{
 			PeerDID:   peerDID,
 			Connected: true,
 			Error:     nil,
 		}, nil
-	case err := <-connectionError:
-		return &ConnectionResult{
-			PeerDID:   peerDID,
-			Connected: false,
-			Error:     err,
-		}, nil
 	case <-ctx.Done():
 		return &ConnectionResult{
 			PeerDID:   peerDID,
 			Connected: false,
-			Error:     fmt.Errorf("connection timeout after %v", timeout),
+			Error:     fmt.Errorf("connection timeout: %w", ctx.Err()),
 		}, nil
 	}
 }
 
+// ConnectionDirection is one direction of a connection between two clients
+type ConnectionDirection struct {
+	// From is the DID of the client that received the introduction
+	From string
+
+	// To is the DID of the peer From can now message
+	To string
+
+	// Peer describes the connection once From has stored To's introduction
+	Peer *PeerInfo
+
+	// Err is why the direction did not complete
+	Err error
+}
+
+// Connected reports whether From can message To
+func (d ConnectionDirection) Connected() bool {
+	return d.Peer != nil
+}
+
+// ClientsConnection is the result of ConnectClients, one entry per direction
+type ClientsConnection struct {
+	// AToB is a's side: a has b's introduction and can message b
+	AToB ConnectionDirection
+
+	// BToA is b's side: b has a's introduction and can message a
+	BToA ConnectionDirection
+}
+
 // ConnectTwoClients is a utility function to establish a bidirectional connection
-// between two clients in the same process (useful for demos and testing)
+// between two clients for testing or direct peer-to-peer scenarios
 func ConnectTwoClients(client1, client2 *Client) error {
 	return ConnectTwoClientsWithTimeout(client1, client2, 30*time.Second)
 }
 
 // ConnectTwoClientsWithTimeout establishes a connection between two clients with a timeout
 func ConnectTwoClientsWithTimeout(client1, client2 *Client, timeout time.Duration) error {
-	if client1.isClosed() || client2.isClosed() {
-		return ErrClientClosed
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	// Get addresses for both clients
-	address1 := signing.FromAddress(client1.DID())
-	address2 := signing.FromAddress(client2.DID())
+	_, err := ConnectClients(ctx, client1, client2)
+	return err
+}
 
-	if address1 == nil {
-		return fmt.Errorf("invalid client1 DID: %s", client1.DID())
+// ConnectClients connects two clients and waits until both have exchanged
+// introductions and can message each other, or ctx is done. The result
+// reports each direction, including the ones that did not complete.
+func ConnectClients(ctx context.Context, a, b *Client) (*ClientsConnection, error) {
+	if a.isClosed() || b.isClosed() {
+		return nil, ErrClientClosed
 	}
-	if address2 == nil {
-		return fmt.Errorf("invalid client2 DID: %s", client2.DID())
+
+	aAddress := signing.FromAddress(a.DID())
+	bAddress := signing.FromAddress(b.DID())
+	if aAddress == nil || bAddress == nil {
+		return nil, ErrInvalidPeerDID
 	}
 
-	// Set up connection tracking
-	connectionEstablished := make(chan bool, 2)
-	connectionCount := 0
+	result := &ClientsConnection{
+		AToB: ConnectionDirection{From: a.DID(), To: b.DID()},
+		BToA: ConnectionDirection{From: b.DID(), To: a.DID()},
+	}
 
-	// Set up handlers for both clients
-	client1.discovery.OnResponse(func(peer *Peer) {
-		if peer.DID() == client2.DID() {
-			connectionEstablished <- true
-		}
-	})
+	// Watch both sides before negotiating so no introduction is missed
+	aReady, unsubscribeA := a.connection.watchReady(b.DID())
+	defer unsubscribeA()
+	bReady, unsubscribeB := b.connection.watchReady(a.DID())
+	defer unsubscribeB()
 
-	client2.discovery.OnResponse(func(peer *Peer) {
-		if peer.DID() == client1.DID() {
-			connectionEstablished <- true
-		}
+	err := runContext(ctx, func() error {
+		return a.transport.ConnectionNegotiate(aAddress, bAddress, expiresFrom(ctx, 30*time.Second))
 	})
-
-	// Initiate connection from client1 to client2
-	err := client1.account.ConnectionNegotiate(
-		address1,
-		address2,
-		time.Now().Add(timeout),
-	)
 	if err != nil {
-		return fmt.Errorf("failed to negotiate connection from client1 to client2: %w", err)
+		err = fmt.Errorf("failed to negotiate connection: %w", err)
+		result.AToB.Err = err
+		result.BToA.Err = err
+		return result, err
 	}
 
-	// Wait for connection establishment
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	for connectionCount < 1 { // We only need one direction to establish the connection
+	for !result.AToB.Connected() || !result.BToA.Connected() {
 		select {
-		case <-connectionEstablished:
-			connectionCount++
+		case peer := <-aReady:
+			result.AToB.Peer = &peer
+		case peer := <-bReady:
+			result.BToA.Peer = &peer
 		case <-ctx.Done():
-			return fmt.Errorf("connection timeout after %v", timeout)
+			err := fmt.Errorf("connection timeout: %w", ctx.Err())
+			if !result.AToB.Connected() {
+				result.AToB.Err = err
+			}
+			if !result.BToA.Connected() {
+				result.BToA.Err = err
+			}
+			return result, err
 		}
 	}
 
-	return nil
+	return result, nil
 }
 
 // IsConnectedTo checks if this client is connected to a specific peer
 func (c *Connection) IsConnectedTo(peerDID string) bool {
-	// This would require tracking active connections
-	// For now, we'll return false as a placeholder
-	// In a full implementation, this would check the connection state
-	return false
+	return c.isConnected(peerDID)
 }
 
-// ListConnectedPeers returns a list of currently connected peer DIDs
+// ListConnectedPeers returns the DIDs of connected peers, sorted
 func (c *Connection) ListConnectedPeers() []string {
-	// This would require tracking active connections
-	// For now, we'll return an empty slice as a placeholder
-	// In a full implementation, this would return actual connected peers
-	return []string{}
+	c.mu.RLock()
+	peers := make([]string, 0, len(c.connections))
+	for peerDID := range c.connections {
+		peers = append(peers, peerDID)
+	}
+	c.mu.RUnlock()
+
+	sort.Strings(peers)
+	return peers
+}
+
+// GetConnection returns the connection with a peer
+func (c *Connection) GetConnection(peerDID string) (*PeerConnection, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	connection, ok := c.connections[peerDID]
+	if !ok {
+		return nil, ErrNotConnected
+	}
+	copied := *connection
+	return &copied, nil
+}
+
+// OnConnectionRejected registers a handler for incoming connections refused
+// by the connection policy
+func (c *Connection) OnConnectionRejected(handler func(ConnectionRejection)) Unsubscribe {
+	return c.onRejectedHandlers.add(handler)
+}
+
+// Disconnect removes a peer, optionally notifying it first
+func (c *Connection) Disconnect(peerDID string, notify bool) error {
+	return c.DisconnectContext(context.Background(), peerDID, notify)
+}
+
+// DisconnectContext removes a peer: its tokens, connection state and contact
+// are deleted, it is dropped from groups, and pending requests and queued
+// content for it are cancelled. It fails with ErrTokenRemovalNotSupported,
+// removing nothing, when the transport does not implement TokenRemover. When
+// notify is set the peer is told first, and nothing is removed if the notice
+// cannot be sent.
+func (c *Connection) DisconnectContext(ctx context.Context, peerDID string, notify bool) error {
+	if c.client.isClosed() {
+		return ErrClientClosed
+	}
+
+	peerAddress := signing.FromAddress(peerDID)
+	if peerAddress == nil {
+		return ErrInvalidPeerDID
+	}
+
+	// A revoked peer must not keep being able to message us
+	if _, ok := c.client.transport.(TokenRemover); !ok {
+		return ErrTokenRemovalNotSupported
+	}
+
+	if notify {
+		payload, err := json.Marshal(customPayload{Type: disconnectNoticeType})
+		if err != nil {
+			return fmt.Errorf("failed to build disconnect notice: %w", err)
+		}
+		content, err := message.NewCustom().
+			Payload(payload).
+			Finish()
+		if err != nil {
+			return fmt.Errorf("failed to build disconnect notice: %w", err)
+		}
+		if err := c.client.sendMessageContext(ctx, peerAddress, *content); err != nil {
+			return fmt.Errorf("failed to notify peer: %w", err)
+		}
+	}
+
+	return c.forget(peerAddress, false)
+}
+
+// OnPeerConnected registers a handler for peers that become usable: the
+// connection is established and the peer's introduction tokens are stored
+func (c *Connection) OnPeerConnected(handler func(PeerInfo)) Unsubscribe {
+	return c.onPeerConnectedHandlers.add(handler)
+}
+
+// OnPeerDisconnected registers a handler for peers that ended their
+// connection with this client
+func (c *Connection) OnPeerDisconnected(handler func(PeerInfo)) Unsubscribe {
+	return c.onPeerDisconnectedHandlers.add(handler)
+}
+
+// OnPeerRemoved registers a handler for connections removed by this client
+func (c *Connection) OnPeerRemoved(handler func(PeerInfo)) Unsubscribe {
+	return c.onPeerRemovedHandlers.add(handler)
+}
+
+// watchReady delivers the peer once it can be messaged, either immediately
+// when its introduction is already stored or when it next connects. The
+// signal comes from onIntroduction directly, so it does not depend on the
+// handler queue.
+func (c *Connection) watchReady(peerDID string) (<-chan PeerInfo, Unsubscribe) {
+	ready := make(chan PeerInfo, 1)
+
+	c.mu.Lock()
+	c.readyWaiters[peerDID] = append(c.readyWaiters[peerDID], ready)
+	connection, ok := c.connections[peerDID]
+	if ok && !connection.LastIntroductionAt.Before(connection.EstablishedAt) {
+		ready <- connection.info()
+	}
+	c.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			waiters := c.readyWaiters[peerDID]
+			for i, waiter := range waiters {
+				if waiter == ready {
+					waiters = append(waiters[:i:i], waiters[i+1:]...)
+					break
+				}
+			}
+			if len(waiters) == 0 {
+				delete(c.readyWaiters, peerDID)
+			} else {
+				c.readyWaiters[peerDID] = waiters
+			}
+		})
+	}
+	return ready, unsubscribe
+}
+
+// signalReady delivers a peer that became usable to its waiters
+func (c *Connection) signalReady(info PeerInfo) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, waiter := range c.readyWaiters[info.DID] {
+		select {
+		case waiter <- info:
+		default:
+		}
+	}
+}
+
+// isConnected reports whether a connection with the peer is tracked
+func (c *Connection) isConnected(peerDID string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.connections[peerDID]
+	return ok
+}
+
+// connectedCount returns the number of tracked connections
+func (c *Connection) connectedCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.connections)
+}
+
+// track updates the connection with a peer, persists it and returns the
+// connection as it was before the update and after it. An untracked peer is
+// only added when create returns true; otherwise ok is false.
+func (c *Connection) track(peerDID string, create func(now time.Time) bool, update func(connection *PeerConnection, now time.Time)) (previous, current PeerConnection, ok bool) {
+	now := time.Now()
+
+	c.mu.Lock()
+	connection, ok := c.connections[peerDID]
+	if !ok {
+		if !create(now) {
+			c.mu.Unlock()
+			return previous, current, false
+		}
+		connection = &PeerConnection{
+			PeerDID:       peerDID,
+			EstablishedAt: now,
+		}
+		c.connections[peerDID] = connection
+	}
+	previous = *connection
+	update(connection, now)
+	current = *connection
+	err := c.persistLocked()
+	c.mu.Unlock()
+
+	c.reportPersist(err)
+	return previous, current, true
+}
+
+// expectOutOfBand records a connection established from a discovery request.
+// The responder only learns the requester's DID from its introduction, so
+// the first introduction received on the connection's group is accepted.
+func (c *Connection) expectOutOfBand(groupAddress *signing.PublicKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.outOfBand[groupAddress.String()] = time.Now().Add(outOfBandIntroductionTimeout)
+}
+
+// takeOutOfBandLocked consumes the pending out-of-band connection on a group
+// with mu held, reporting whether there was one. Expired entries are dropped.
+func (c *Connection) takeOutOfBandLocked(group string, now time.Time) bool {
+	for pending, expires := range c.outOfBand {
+		if !now.Before(expires) {
+			delete(c.outOfBand, pending)
+		}
+	}
+
+	if _, ok := c.outOfBand[group]; !ok {
+		return false
+	}
+	delete(c.outOfBand, group)
+	return true
+}
+
+// forget tears down everything held for a peer. byPeer says whether the
+// peer ended the connection. Token removal errors are returned after the
+// rest of the teardown.
+func (c *Connection) forget(peerAddress *signing.PublicKey, byPeer bool) error {
+	peerDID := peerAddress.String()
+
+	var err error
+	if remover, ok := c.client.transport.(TokenRemover); ok {
+		if err = remover.TokenRemove(peerAddress, c.client.inboxAddress); err != nil {
+			err = fmt.Errorf("failed to remove tokens: %w", err)
+		}
+	} else {
+		// Only reached when the peer ended the connection
+		c.client.logger.Info("peer tokens kept, transport cannot remove them",
+			logKeyComponent, ComponentConnection,
+			logKeyPeer, peerDID,
+		)
+	}
+
+	for _, cancelled := range c.client.requests.cancelPeer(peerDID, ErrPeerDisconnected) {
+		c.client.logger.Debug("request cancelled",
+			logKeyRequestID, cancelled.ID,
+			logKeyPeer, peerDID,
+			"kind", cancelled.Kind.String(),
+		)
+	}
+	if c.client.outbox != nil {
+		c.client.outbox.dropPeer(peerDID, ErrPeerDisconnected)
+	}
+	if c.client.groupChats != nil {
+		c.client.groupChats.removeMember(peerDID)
+	}
+	if c.client.contacts != nil {
+		c.client.contacts.forget(peerDID)
+	}
+
+	c.remove(peerDID, byPeer)
+	return err
+}
+
+// onDisconnectNotice handles a peer ending the connection, reporting
+// whether the message was a disconnect notice
+func (c *Connection) onDisconnectNotice(msg InboundMessage) bool {
+	custom, err := message.DecodeCustom(msg.Content())
+	if err != nil {
+		return false
+	}
+	var payload customPayload
+	if err := json.Unmarshal(custom.Payload(), &payload); err != nil || payload.Type != disconnectNoticeType {
+		return false
+	}
+
+	if err := c.forget(msg.FromAddress(), true); err != nil {
+		c.client.reportMessageError(ComponentConnection, "Disconnect", msg, err)
+	}
+	return true
+}
+
+// remove stops tracking a peer. byPeer says whether the peer ended the
+// connection, which decides the lifecycle event reported.
+func (c *Connection) remove(peerDID string, byPeer bool) bool {
+	var err error
+	c.mu.Lock()
+	connection, ok := c.connections[peerDID]
+	if ok {
+		delete(c.connections, peerDID)
+		err = c.persistLocked()
+	}
+	c.mu.Unlock()
+
+	c.reportPersist(err)
+	if !ok {
+		return false
+	}
+
+	info := connection.info()
+	if byPeer {
+		c.notifyPeer(&c.onPeerDisconnectedHandlers, EventPeerDisconnected, info)
+	} else {
+		c.notifyPeer(&c.onPeerRemovedHandlers, EventPeerRemoved, info)
+	}
+	return true
+}
+
+// notifyPeer reports a peer lifecycle event to handlers and event streams
+func (c *Connection) notifyPeer(handlers *handlerList[func(PeerInfo)], eventType EventType, info PeerInfo) {
+	c.client.logger.Info("peer lifecycle event",
+		logKeyComponent, ComponentConnection,
+		logKeyPeer, info.DID,
+		"event", eventType,
+	)
+
+	for _, handler := range handlers.snapshot() {
+		c.client.runHandler(ComponentConnection, info.DID, func() { handler(info) })
+	}
+
+	c.client.publish(Event{Type: eventType, PeerDID: info.DID, PeerInfo: &info})
+}
+
+// persistLocked writes the tracked connections to encrypted storage with mu
+// held. The caller reports the error with reportPersist once mu is released.
+func (c *Connection) persistLocked() error {
+	return c.client.storage.StoreJSON(connectionsStorageKey, c.connections)
+}
+
+// reportPersist reports a failure to persist the tracked connections
+func (c *Connection) reportPersist(err error) {
+	if err != nil {
+		c.client.reportError(ErrorEvent{
+			Component: ComponentConnection,
+			Operation: "Persist",
+			Err:       err,
+		})
+	}
+}
+
+// load restores the tracked connections from encrypted storage
+func (c *Connection) load() {
+	var connections map[string]*PeerConnection
+	if err := c.client.storage.LookupJSON(connectionsStorageKey, &connections); err != nil {
+		// No connections have been tracked yet
+		return
+	}
+	for peerDID, connection := range connections {
+		c.connections[peerDID] = connection
+	}
 }
 
 // Internal methods for handling events
@@ -210,14 +649,76 @@ func (c *Connection) onDisconnect(err error) {
 
 func (c *Connection) onWelcome(from *signing.PublicKey, groupAddress *signing.PublicKey) {
 	// New peer connection established
+	c.onEstablished(from, groupAddress)
 }
 
-func (c *Connection) onKeyPackage(from *signing.PublicKey) {
-	// Key package received from peer
+func (c *Connection) onKeyPackage(from *signing.PublicKey, groupAddress *signing.PublicKey) {
+	// Key package received from peer and connection established
+	c.onEstablished(from, groupAddress)
 }
 
-func (c *Connection) onIntroduction(from *signing.PublicKey, tokenCount int) {
-	// Introduction received from peer
+func (c *Connection) onEstablished(from *signing.PublicKey, groupAddress *signing.PublicKey) {
+	group := groupAddress.String()
+	create := func(time.Time) bool { return true }
+	c.track(from.String(), create, func(connection *PeerConnection, now time.Time) {
+		if connection.GroupAddress != group {
+			connection.GroupAddress = group
+			connection.EstablishedAt = now
+		}
+	})
+}
+
+func (c *Connection) onRejected(rejection ConnectionRejection) {
+	// Notify handlers
+	handlers := c.onRejectedHandlers.snapshot()
+
+	for _, handler := range handlers {
+		c.client.runHandler(ComponentConnection, rejection.PeerDID, func() { handler(rejection) })
+	}
+
+	c.client.publish(Event{
+		Type:      EventConnectionRejected,
+		Time:      rejection.Time,
+		PeerDID:   rejection.PeerDID,
+		Rejection: &rejection,
+		Cause:     rejection.Reason,
+	})
+}
+
+func (c *Connection) onIntroduction(from, to *signing.PublicKey, tokenCount int) {
+	// Introduction received from peer - the peer can now be messaged. Only
+	// connections that were established, in band or out of band, are tracked.
+	group := to.String()
+	created := false
+	create := func(now time.Time) bool {
+		created = c.takeOutOfBandLocked(group, now)
+		return created
+	}
+	previous, current, ok := c.track(from.String(), create, func(connection *PeerConnection, now time.Time) {
+		if connection.GroupAddress == "" {
+			connection.GroupAddress = group
+		}
+		connection.TokensReceived += tokenCount
+		connection.LastIntroductionAt = now
+	})
+	if !ok {
+		c.client.logger.Debug("introduction from untracked peer",
+			logKeyComponent, ComponentConnection,
+			logKeyPeer, from.String(),
+		)
+		return
+	}
+	if created && c.client.contacts != nil {
+		// A requester connected out of band is only known from now on
+		c.client.contacts.add(current.PeerDID)
+	}
+
+	// The first introduction since the connection was established makes the peer usable
+	if previous.LastIntroductionAt.Before(previous.EstablishedAt) {
+		info := current.info()
+		c.signalReady(info)
+		c.notifyPeer(&c.onPeerConnectedHandlers, EventPeerConnected, info)
+	}
 }
 
 func (c *Connection) close() {
@@ -1,9 +1,14 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/joinself/self-go-sdk/keypair/signing"
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/joinself/self-go-sdk/token"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -107,8 +112,8 @@ func TestConnectionComponentMethods(t *testing.T) {
 	conn.onConnect()
 	conn.onDisconnect(nil)
 	conn.onWelcome(nil, nil)
-	conn.onKeyPackage(nil)
-	conn.onIntroduction(nil, 0)
+	conn.onKeyPackage(nil, nil)
+	conn.onIntroduction(nil, nil, 0)
 	conn.close()
 }
 
@@ -131,3 +136,269 @@ func TestConnectionComponentStructure(t *testing.T) {
 	var conn *Connection
 	assert.Nil(t, conn) // Just to use the variable
 }
+
+func TestConnectionTracking(t *testing.T) {
+	transport := newFakeTransport(t)
+	client, err := New(Config{Transport: transport.factory()})
+	require.NoError(t, err)
+
+	peer := testAddress(t)
+	conn := client.Connection()
+
+	_, err = conn.GetConnection(peer.String())
+	assert.ErrorIs(t, err, ErrNotConnected)
+
+	// A welcome establishes the connection
+	transport.callbacks.OnWelcome(peer, transport.address, nil)
+	assert.True(t, conn.IsConnectedTo(peer.String()))
+	assert.Equal(t, []string{peer.String()}, conn.ListConnectedPeers())
+
+	connection, err := conn.GetConnection(peer.String())
+	require.NoError(t, err)
+	assert.Equal(t, peer.String(), connection.PeerDID)
+	assert.Equal(t, transport.address.String(), connection.GroupAddress)
+	assert.False(t, connection.EstablishedAt.IsZero())
+	assert.Zero(t, connection.TokensReceived)
+
+	// Introductions add to the token count
+	introduction, err := message.NewIntroduction().
+		DocumentAddress(peer).
+		Token(new(token.Token)).
+		Token(new(token.Token)).
+		Finish()
+	require.NoError(t, err)
+	transport.receive(peer, introduction)
+
+	connection, err = conn.GetConnection(peer.String())
+	require.NoError(t, err)
+	assert.Equal(t, 2, connection.TokensReceived)
+	assert.False(t, connection.LastIntroductionAt.IsZero())
+
+	// Connections are restored from storage by the next client
+	require.NoError(t, client.Close())
+	restarted, err := New(Config{Transport: transport.factory()})
+	require.NoError(t, err)
+	defer restarted.Close()
+
+	restored, err := restarted.Connection().GetConnection(peer.String())
+	require.NoError(t, err)
+	assert.Equal(t, connection.GroupAddress, restored.GroupAddress)
+	assert.Equal(t, 2, restored.TokensReceived)
+}
+
+func TestConnectionIntroductionOnlyUpdates(t *testing.T) {
+	client, transport := newTestClient(t)
+	conn := client.Connection()
+
+	introduce := func(peer, to *signing.PublicKey) {
+		introduction, err := message.NewIntroduction().
+			DocumentAddress(peer).
+			Token(new(token.Token)).
+			Finish()
+		require.NoError(t, err)
+		transport.receiveOn(peer, to, introduction)
+	}
+
+	// An introduction alone does not create a connection
+	stranger := testAddress(t)
+	introduce(stranger, transport.address)
+	assert.False(t, conn.IsConnectedTo(stranger.String()))
+
+	// After answering a discovery request the requester is only known from
+	// its introduction on the group established for the request, which is
+	// accepted once
+	group := testAddress(t)
+	conn.expectOutOfBand(group)
+	introduce(stranger, transport.address)
+	assert.False(t, conn.IsConnectedTo(stranger.String()))
+
+	requester := testAddress(t)
+	introduce(requester, group)
+	assert.True(t, conn.IsConnectedTo(requester.String()))
+	connection, err := conn.GetConnection(requester.String())
+	require.NoError(t, err)
+	assert.Equal(t, group.String(), connection.GroupAddress)
+	_, err = client.Contacts().Get(requester.String())
+	assert.NoError(t, err)
+
+	introduce(stranger, group)
+	assert.False(t, conn.IsConnectedTo(stranger.String()))
+}
+
+func TestPeerConnectedEvent(t *testing.T) {
+	transport := newFakeTransport(t)
+	client, err := New(Config{Transport: transport.factory()})
+	require.NoError(t, err)
+	defer client.Close()
+
+	peer := testAddress(t)
+	connected := make(chan PeerInfo, 4)
+	client.Connection().OnPeerConnected(func(info PeerInfo) {
+		connected <- info
+	})
+
+	introduce := func() {
+		introduction, err := message.NewIntroduction().
+			DocumentAddress(peer).
+			Token(new(token.Token)).
+			Finish()
+		require.NoError(t, err)
+		transport.receive(peer, introduction)
+	}
+
+	// The peer is connected once its introduction tokens are stored
+	transport.callbacks.OnWelcome(peer, transport.address, nil)
+	assert.Empty(t, connected)
+	introduce()
+
+	select {
+	case info := <-connected:
+		assert.Equal(t, peer.String(), info.DID)
+		assert.Equal(t, transport.address.String(), info.GroupAddress)
+		assert.Equal(t, 1, info.TokensReceived)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for peer connected")
+	}
+
+	// Later introductions do not report the peer again
+	introduce()
+	assert.Never(t, func() bool { return len(connected) > 0 }, 50*time.Millisecond, 5*time.Millisecond)
+
+	// Removing the connection reports it once
+	removed := make(chan PeerInfo, 1)
+	client.Connection().OnPeerRemoved(func(info PeerInfo) {
+		removed <- info
+	})
+	assert.True(t, client.Connection().remove(peer.String(), false))
+	assert.False(t, client.Connection().remove(peer.String(), false))
+	assert.Equal(t, peer.String(), (<-removed).DID)
+	assert.False(t, client.Connection().IsConnectedTo(peer.String()))
+}
+
+func TestDisconnect(t *testing.T) {
+	transport := newFakeTransport(t)
+	client, err := New(Config{Transport: transport.factory()})
+	require.NoError(t, err)
+	defer client.Close()
+
+	peer := testAddress(t)
+	conn := client.Connection()
+	transport.callbacks.OnWelcome(peer, transport.address, nil)
+
+	removed := make(chan PeerInfo, 1)
+	conn.OnPeerRemoved(func(info PeerInfo) {
+		removed <- info
+	})
+
+	// Pending requests to the peer are cancelled
+	request := newPendingRequest[*Peer]("request", RequestCredentialPresentation, peer.String(), time.Time{})
+	require.NoError(t, client.trackRequest(request))
+
+	require.NoError(t, conn.Disconnect(peer.String(), false))
+	assert.Equal(t, 1, transport.removed)
+
+	_, err = request.wait(context.Background())
+	assert.ErrorIs(t, err, ErrPeerDisconnected)
+	assert.Empty(t, client.PendingRequests())
+	assert.False(t, conn.IsConnectedTo(peer.String()))
+	assert.Equal(t, peer.String(), (<-removed).DID)
+	assert.Zero(t, transport.sentCount())
+
+	// Notifying the peer sends a notice first
+	require.NoError(t, conn.Disconnect(peer.String(), true))
+	require.Equal(t, 1, transport.sentCount())
+	assert.Equal(t, message.ContentTypeCustom, transport.sent[0].ContentType())
+
+	transport.failSends(errors.New("network unreachable"))
+	assert.Error(t, conn.Disconnect(peer.String(), true))
+}
+
+func TestDisconnectRequiresTokenRemoval(t *testing.T) {
+	transport := newFakeTransport(t)
+
+	// Hide TokenRemove behind the plain Transport interface
+	client, err := New(Config{Transport: func(config *Config, callbacks TransportCallbacks) (Transport, error) {
+		transport.callbacks = callbacks
+		return struct{ Transport }{transport}, nil
+	}})
+	require.NoError(t, err)
+	defer client.Close()
+
+	peer := testAddress(t)
+	transport.callbacks.OnWelcome(peer, transport.address, nil)
+
+	// Nothing is removed or sent when the peer's tokens cannot be deleted
+	assert.ErrorIs(t, client.Connection().Disconnect(peer.String(), true), ErrTokenRemovalNotSupported)
+	assert.True(t, client.Connection().IsConnectedTo(peer.String()))
+	assert.Zero(t, transport.sentCount())
+}
+
+func TestConnectClientsTimeout(t *testing.T) {
+	a, _ := newTestClient(t)
+	b, bTransport := newTestClient(t)
+
+	// Only b receives an introduction, so only b's direction completes
+	introduction, err := message.NewIntroduction().
+		DocumentAddress(signing.FromAddress(a.DID())).
+		Finish()
+	require.NoError(t, err)
+	bTransport.callbacks.OnWelcome(signing.FromAddress(a.DID()), bTransport.address, nil)
+	bTransport.receive(signing.FromAddress(a.DID()), introduction)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	result, err := ConnectClients(ctx, a, b)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NotNil(t, result)
+	assert.False(t, result.AToB.Connected())
+	assert.ErrorIs(t, result.AToB.Err, context.DeadlineExceeded)
+	assert.True(t, result.BToA.Connected())
+	assert.NoError(t, result.BToA.Err)
+	assert.Equal(t, a.DID(), result.BToA.Peer.DID)
+}
+
+func TestWatchReadyIgnoresHandlerQueue(t *testing.T) {
+	transport := newFakeTransport(t)
+	client, err := New(Config{
+		Transport: transport.factory(),
+		Dispatch:  DispatchConfig{Workers: 1, QueueSize: 1, Policy: DispatchDrop},
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	// A stuck handler holds the only worker and fills the queue
+	release := make(chan struct{})
+	defer close(release)
+	client.Connection().OnPeerConnected(func(PeerInfo) { <-release })
+
+	blocker := testAddress(t)
+	peer := testAddress(t)
+	introduce := func(from *signing.PublicKey) {
+		transport.callbacks.OnWelcome(from, transport.address, nil)
+		introduction, err := message.NewIntroduction().
+			DocumentAddress(from).
+			Token(new(token.Token)).
+			Finish()
+		require.NoError(t, err)
+		transport.receive(from, introduction)
+	}
+	introduce(blocker)
+
+	ready, unsubscribe := client.Connection().watchReady(peer.String())
+	defer unsubscribe()
+	introduce(peer)
+
+	select {
+	case info := <-ready:
+		assert.Equal(t, peer.String(), info.DID)
+	case <-time.After(time.Second):
+		t.Fatal("readiness was not signalled")
+	}
+
+	// Unsubscribed waiters are released
+	unsubscribe()
+	client.Connection().mu.RLock()
+	assert.Empty(t, client.Connection().readyWaiters)
+	client.Connection().mu.RUnlock()
+}
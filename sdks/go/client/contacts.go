@@ -0,0 +1,449 @@
+package client
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joinself/self-go-sdk/credential"
+	"github.com/joinself/self-go-sdk/keypair/signing"
+)
+
+// contactsStorageKey is where contacts are persisted in encrypted storage
+const contactsStorageKey = "self-client:contacts"
+
+// interactionPersistInterval limits how often interaction times alone are persisted
+const interactionPersistInterval = time.Minute
+
+// Contact is a peer profile kept in the contact book
+type Contact struct {
+	// DID is the peer's DID
+	DID string
+
+	// DisplayName is the name chosen for the peer
+	DisplayName string
+
+	// Notes are free-form notes about the peer
+	Notes string
+
+	// Tags group contacts for searching
+	Tags []string
+
+	// FirstInteraction is when the peer was first seen
+	FirstInteraction time.Time
+
+	// LastInteraction is when the peer was last seen
+	LastInteraction time.Time
+
+	// VerifiedClaims are subject claims from valid credentials about the peer,
+	// issued by someone else, that it presented in accepted responses
+	VerifiedClaims map[string]interface{}
+}
+
+// VerifiedName returns the name from a verified profile credential, if any
+func (c *Contact) VerifiedName() (string, bool) {
+	firstName, firstOk := c.VerifiedClaims["firstName"].(string)
+	lastName, lastOk := c.VerifiedClaims["lastName"].(string)
+	if !firstOk || !lastOk {
+		return "", false
+	}
+	return strings.TrimSpace(firstName + " " + lastName), true
+}
+
+// copy returns a deep copy of the contact
+func (c *Contact) copy() *Contact {
+	copied := *c
+	copied.Tags = slices.Clone(c.Tags)
+	copied.VerifiedClaims = maps.Clone(c.VerifiedClaims)
+	return &copied
+}
+
+// matches reports whether the contact contains the lower case query
+func (c *Contact) matches(query string) bool {
+	fields := []string{c.DID, c.DisplayName, c.Notes}
+	fields = append(fields, c.Tags...)
+	for _, value := range c.VerifiedClaims {
+		if s, ok := value.(string); ok {
+			fields = append(fields, s)
+		}
+	}
+
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contacts is the contact book. Peers are added when a connection is
+// established and kept in encrypted storage.
+type Contacts struct {
+	client *Client
+
+	// Contacts by peer DID
+	contacts  map[string]*Contact
+	persisted map[string]time.Time
+	mu        sync.RWMutex
+}
+
+// newContacts creates a new contacts component, restoring contacts from storage
+func newContacts(client *Client) *Contacts {
+	c := &Contacts{
+		client:    client,
+		contacts:  make(map[string]*Contact),
+		persisted: make(map[string]time.Time),
+	}
+	c.load()
+	return c
+}
+
+// Get returns the contact for a peer
+func (c *Contacts) Get(peerDID string) (*Contact, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	contact, ok := c.contacts[peerDID]
+	if !ok {
+		return nil, ErrContactNotFound
+	}
+	return contact.copy(), nil
+}
+
+// List returns all contacts, sorted by display name
+func (c *Contacts) List() []*Contact {
+	return c.Search("")
+}
+
+// Search returns the contacts whose DID, display name, notes, tags or
+// verified claims contain query, ignoring case, sorted by display name
+func (c *Contacts) Search(query string) []*Contact {
+	query = strings.ToLower(query)
+
+	c.mu.RLock()
+	var found []*Contact
+	for _, contact := range c.contacts {
+		if contact.matches(query) {
+			found = append(found, contact.copy())
+		}
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(found, func(i, j int) bool {
+		a, b := c.nameOf(found[i]), c.nameOf(found[j])
+		if a != b {
+			return a < b
+		}
+		return found[i].DID < found[j].DID
+	})
+	return found
+}
+
+// Update changes a contact, adding it if the peer is not in the contact book
+func (c *Contacts) Update(peerDID string, update func(contact *Contact)) (*Contact, error) {
+	if c.client.isClosed() {
+		return nil, ErrClientClosed
+	}
+	if signing.FromAddress(peerDID) == nil {
+		return nil, ErrInvalidPeerDID
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	contact, ok := c.contacts[peerDID]
+	if !ok {
+		contact = &Contact{DID: peerDID}
+		c.contacts[peerDID] = contact
+	}
+	update(contact)
+	contact.DID = peerDID
+
+	if err := c.persistLocked(); err != nil {
+		return nil, err
+	}
+	c.persisted[peerDID] = contact.LastInteraction
+	return contact.copy(), nil
+}
+
+// Remove deletes a contact
+func (c *Contacts) Remove(peerDID string) error {
+	if c.client.isClosed() {
+		return ErrClientClosed
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.contacts[peerDID]; !ok {
+		return ErrContactNotFound
+	}
+	delete(c.contacts, peerDID)
+	delete(c.persisted, peerDID)
+	return c.persistLocked()
+}
+
+// Export returns all contacts as a JSON array, sorted by DID
+func (c *Contacts) Export() ([]byte, error) {
+	c.mu.RLock()
+	contacts := make([]*Contact, 0, len(c.contacts))
+	for _, contact := range c.contacts {
+		contacts = append(contacts, contact)
+	}
+	sort.Slice(contacts, func(i, j int) bool {
+		return contacts[i].DID < contacts[j].DID
+	})
+	data, err := json.Marshal(contacts)
+	c.mu.RUnlock()
+
+	return data, err
+}
+
+// DisplayName returns the name to show for a peer: the contact's display
+// name, then its verified name, then the DID
+func (c *Contacts) DisplayName(peerDID string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	contact, ok := c.contacts[peerDID]
+	if !ok {
+		return peerDID
+	}
+	return c.nameOf(contact)
+}
+
+// nameOf returns the display name of a contact
+func (c *Contacts) nameOf(contact *Contact) string {
+	if contact.DisplayName != "" {
+		return contact.DisplayName
+	}
+	if name, ok := contact.VerifiedName(); ok {
+		return name
+	}
+	return contact.DID
+}
+
+// add records an interaction with a peer whose connection was established,
+// adding it to the contact book
+func (c *Contacts) add(peerDID string) {
+	c.touch(peerDID, true)
+}
+
+// seen records an interaction with a peer already in the contact book
+func (c *Contacts) seen(peerDID string) {
+	c.touch(peerDID, false)
+}
+
+// touch records an interaction with a peer, adding it to the contact book
+// when create is set. Interaction times alone are persisted at most once per
+// interval.
+func (c *Contacts) touch(peerDID string, create bool) {
+	now := time.Now()
+
+	c.mu.Lock()
+	contact, ok := c.contacts[peerDID]
+	if !ok {
+		if !create {
+			c.mu.Unlock()
+			return
+		}
+		contact = &Contact{DID: peerDID, FirstInteraction: now}
+		c.contacts[peerDID] = contact
+	}
+	contact.LastInteraction = now
+
+	if ok && now.Sub(c.persisted[peerDID]) < interactionPersistInterval {
+		c.mu.Unlock()
+		return
+	}
+	err := c.persistLocked()
+	c.persisted[peerDID] = now
+	c.mu.Unlock()
+
+	c.reportPersist(err)
+}
+
+// subjectCredential is the part of a verifiable credential that claims are
+// read from
+type subjectCredential interface {
+	Validate() error
+	Issuer() *credential.Address
+	CredentialSubject() *credential.Address
+	CredentialSubjectClaims() (map[string]interface{}, error)
+}
+
+// recordCredentials adds the subject claims of credentials a peer presented.
+// Only credentials that validate, are about the peer itself and come from a
+// trusted issuer are recorded.
+func (c *Contacts) recordCredentials(peerDID string, credentials []*credential.VerifiableCredential) {
+	subjects := make([]subjectCredential, len(credentials))
+	for i, cred := range credentials {
+		subjects[i] = cred
+	}
+	c.recordClaims(peerDID, subjects)
+}
+
+// recordPresentations adds the claims of the credentials in presentations
+func (c *Contacts) recordPresentations(peerDID string, presentations []*credential.VerifiablePresentation) {
+	var credentials []*credential.VerifiableCredential
+	for _, presentation := range presentations {
+		credentials = append(credentials, presentation.Credentials()...)
+	}
+	c.recordCredentials(peerDID, credentials)
+}
+
+// recordClaims merges the subject claims of the peer's valid credentials
+// into its verified claims
+func (c *Contacts) recordClaims(peerDID string, credentials []subjectCredential) {
+	peerAddress := signing.FromAddress(peerDID)
+	if peerAddress == nil {
+		return
+	}
+	subject := credential.AddressKey(peerAddress).String()
+
+	claims := make(map[string]interface{})
+	for _, cred := range credentials {
+		if err := cred.Validate(); err != nil {
+			c.client.reportError(ErrorEvent{
+				Component: ComponentContacts,
+				Operation: "Validate",
+				PeerDID:   peerDID,
+				Err:       err,
+			})
+			continue
+		}
+		if cred.CredentialSubject() == nil || cred.CredentialSubject().String() != subject {
+			c.client.logger.Debug("credential subject is not the sender, claims not recorded",
+				logKeyComponent, ComponentContacts,
+				logKeyPeer, peerDID,
+			)
+			continue
+		}
+		if !c.trustedIssuer(cred.Issuer(), subject) {
+			c.client.logger.Debug("credential issuer is not trusted, claims not recorded",
+				logKeyComponent, ComponentContacts,
+				logKeyPeer, peerDID,
+			)
+			continue
+		}
+
+		subjectClaims, err := cred.CredentialSubjectClaims()
+		if err != nil {
+			c.client.reportError(ErrorEvent{
+				Component: ComponentContacts,
+				Operation: "CredentialSubjectClaims",
+				PeerDID:   peerDID,
+				Err:       err,
+			})
+			continue
+		}
+		maps.Copy(claims, subjectClaims)
+	}
+	if len(claims) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	contact, ok := c.contacts[peerDID]
+	if !ok {
+		// Only peers with an established connection are contacts
+		c.mu.Unlock()
+		return
+	}
+	if contact.VerifiedClaims == nil {
+		contact.VerifiedClaims = make(map[string]interface{})
+	}
+	maps.Copy(contact.VerifiedClaims, claims)
+	err := c.persistLocked()
+	c.mu.Unlock()
+
+	c.reportPersist(err)
+}
+
+// trustedIssuer reports whether claims from issuer are recorded. A subject
+// vouching for itself is never trusted.
+func (c *Contacts) trustedIssuer(issuer *credential.Address, subject string) bool {
+	if issuer == nil || issuer.String() == "" || issuer.String() == subject {
+		return false
+	}
+	trusted := c.client.config.TrustedIssuers
+	return len(trusted) == 0 || slices.Contains(trusted, issuer.String())
+}
+
+// forget drops a peer from the contact book
+func (c *Contacts) forget(peerDID string) {
+	c.mu.Lock()
+	if _, ok := c.contacts[peerDID]; !ok {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.contacts, peerDID)
+	delete(c.persisted, peerDID)
+	err := c.persistLocked()
+	c.mu.Unlock()
+
+	c.reportPersist(err)
+}
+
+// persistLocked writes the contacts to encrypted storage with mu held. The
+// caller reports the error with reportPersist once mu is released.
+func (c *Contacts) persistLocked() error {
+	return c.client.storage.StoreJSON(contactsStorageKey, c.contacts)
+}
+
+// reportPersist reports a failure to persist the contacts
+func (c *Contacts) reportPersist(err error) {
+	if err != nil {
+		c.client.reportError(ErrorEvent{
+			Component: ComponentContacts,
+			Operation: "Persist",
+			Err:       err,
+		})
+	}
+}
+
+// load restores the contacts from encrypted storage
+func (c *Contacts) load() {
+	var contacts map[string]*Contact
+	if err := c.client.storage.LookupJSON(contactsStorageKey, &contacts); err != nil {
+		// No contacts have been added yet
+		return
+	}
+	for peerDID, contact := range contacts {
+		c.contacts[peerDID] = contact
+		c.persisted[peerDID] = contact.LastInteraction
+	}
+}
+
+// Internal methods for handling events
+
+func (c *Contacts) onConnect() {
+	// Connection established - no specific action needed
+}
+
+func (c *Contacts) onDisconnect(err error) {
+	// Connection lost - no specific action needed
+}
+
+func (c *Contacts) onWelcome(from *signing.PublicKey, groupAddress *signing.PublicKey) {
+	// New connection established - add the peer to the contact book
+	c.add(from.String())
+}
+
+func (c *Contacts) onKeyPackage(from *signing.PublicKey, groupAddress *signing.PublicKey) {
+	// Connection established from a key package - add the peer to the contact book
+	c.add(from.String())
+}
+
+func (c *Contacts) onIntroduction(from *signing.PublicKey, tokenCount int) {
+	// Introduction received - peers connected out of band are added by the
+	// connection component once it tracks them
+}
+
+func (c *Contacts) close() {
+	// Contacts are persisted as they change
+}
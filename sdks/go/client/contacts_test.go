@@ -0,0 +1,285 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/joinself/self-go-sdk/credential"
+	"github.com/joinself/self-go-sdk/keypair/signing"
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContacts(t *testing.T) {
+	transport := newFakeTransport(t)
+	client, err := New(Config{Transport: transport.factory()})
+	require.NoError(t, err)
+
+	alice := testAddress(t)
+	bob := testAddress(t)
+	contacts := client.Contacts()
+
+	_, err = contacts.Get(alice.String())
+	assert.ErrorIs(t, err, ErrContactNotFound)
+	assert.Equal(t, alice.String(), contacts.DisplayName(alice.String()))
+
+	// Established connections are added to the contact book
+	transport.callbacks.OnWelcome(alice, transport.address, nil)
+	contact, err := contacts.Get(alice.String())
+	require.NoError(t, err)
+	assert.False(t, contact.FirstInteraction.IsZero())
+	assert.Equal(t, contact.FirstInteraction, contact.LastInteraction)
+
+	contact, err = contacts.Update(alice.String(), func(contact *Contact) {
+		contact.DisplayName = "Alice"
+		contact.Notes = "Met at the conference"
+		contact.Tags = append(contact.Tags, "customer")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", contact.DisplayName)
+	assert.Equal(t, "Alice", contacts.DisplayName(alice.String()))
+
+	_, err = contacts.Update(bob.String(), func(contact *Contact) {
+		contact.Tags = []string{"supplier"}
+	})
+	require.NoError(t, err)
+
+	// Search matches names, notes and tags, ignoring case
+	found := contacts.Search("CONFERENCE")
+	require.Len(t, found, 1)
+	assert.Equal(t, alice.String(), found[0].DID)
+	assert.Len(t, contacts.Search("supplier"), 1)
+	assert.Len(t, contacts.List(), 2)
+
+	// Returned contacts are copies
+	found[0].Tags[0] = "changed"
+	contact, err = contacts.Get(alice.String())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"customer"}, contact.Tags)
+
+	data, err := contacts.Export()
+	require.NoError(t, err)
+	var exported []*Contact
+	require.NoError(t, json.Unmarshal(data, &exported))
+	assert.Len(t, exported, 2)
+
+	// Chat messages carry the sender's display name
+	received := make(chan ChatMessage, 1)
+	client.Chat().OnMessage(func(msg ChatMessage) {
+		received <- msg
+	})
+	chat, err := message.NewChat().Message("hello").Finish()
+	require.NoError(t, err)
+	transport.receive(alice, chat)
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "Alice", msg.FromName())
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for chat message")
+	}
+
+	// Contacts are restored from storage by the next client
+	require.NoError(t, contacts.Remove(bob.String()))
+	assert.ErrorIs(t, contacts.Remove(bob.String()), ErrContactNotFound)
+	require.NoError(t, client.Close())
+
+	restarted, err := New(Config{Transport: transport.factory()})
+	require.NoError(t, err)
+	defer restarted.Close()
+
+	restored := restarted.Contacts().List()
+	require.Len(t, restored, 1)
+	assert.Equal(t, "Alice", restored[0].DisplayName)
+	assert.Equal(t, "Met at the conference", restored[0].Notes)
+}
+
+// testCredential is a credential with fixed validity, issuer, subject and claims
+type testCredential struct {
+	err     error
+	issuer  *credential.Address
+	subject *credential.Address
+	claims  map[string]interface{}
+}
+
+func (c *testCredential) Validate() error                        { return c.err }
+func (c *testCredential) Issuer() *credential.Address            { return c.issuer }
+func (c *testCredential) CredentialSubject() *credential.Address { return c.subject }
+func (c *testCredential) CredentialSubjectClaims() (map[string]interface{}, error) {
+	return c.claims, nil
+}
+
+func TestContactsRecordClaims(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	errorEvents := make(chan ErrorEvent, 1)
+	client.OnError(func(event ErrorEvent) {
+		errorEvents <- event
+	})
+
+	alice := testAddress(t)
+	bob := testAddress(t)
+	issuer := credential.AddressKey(testAddress(t))
+	contacts := client.Contacts()
+	transport.callbacks.OnWelcome(alice, transport.address, nil)
+
+	contacts.recordClaims(alice.String(), []subjectCredential{
+		&testCredential{
+			issuer:  issuer,
+			subject: credential.AddressKey(alice),
+			claims:  map[string]interface{}{"firstName": "Alice", "lastName": "Smith"},
+		},
+		// Claims about someone else are not the sender's
+		&testCredential{
+			issuer:  issuer,
+			subject: credential.AddressKey(bob),
+			claims:  map[string]interface{}{"firstName": "Bob"},
+		},
+		// Self-signed credentials vouch for nothing
+		&testCredential{
+			issuer:  credential.AddressKey(alice),
+			subject: credential.AddressKey(alice),
+			claims:  map[string]interface{}{"firstName": "Queen"},
+		},
+		// Invalid credentials are skipped
+		&testCredential{
+			err:     errors.New("invalid signature"),
+			issuer:  issuer,
+			subject: credential.AddressKey(alice),
+			claims:  map[string]interface{}{"email": "alice@example.com"},
+		},
+	})
+
+	contact, err := contacts.Get(alice.String())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"firstName": "Alice", "lastName": "Smith"}, contact.VerifiedClaims)
+
+	select {
+	case event := <-errorEvents:
+		assert.Equal(t, ComponentContacts, event.Component)
+		assert.Equal(t, "Validate", event.Operation)
+		assert.Equal(t, alice.String(), event.PeerDID)
+	case <-time.After(time.Second):
+		t.Fatal("invalid credential not reported")
+	}
+
+	// Claims never add a peer to the contact book
+	contacts.recordClaims(bob.String(), []subjectCredential{
+		&testCredential{
+			issuer:  issuer,
+			subject: credential.AddressKey(bob),
+			claims:  map[string]interface{}{"firstName": "Bob"},
+		},
+	})
+	_, err = contacts.Get(bob.String())
+	assert.ErrorIs(t, err, ErrContactNotFound)
+}
+
+func TestContactsTrustedIssuers(t *testing.T) {
+	trusted := credential.AddressKey(testAddress(t))
+	transport := newFakeTransport(t)
+	client, err := New(Config{
+		Transport:      transport.factory(),
+		TrustedIssuers: []string{trusted.String()},
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	alice := testAddress(t)
+	transport.callbacks.OnWelcome(alice, transport.address, nil)
+
+	// Only issuers on the list are recorded
+	client.Contacts().recordClaims(alice.String(), []subjectCredential{
+		&testCredential{
+			issuer:  credential.AddressKey(testAddress(t)),
+			subject: credential.AddressKey(alice),
+			claims:  map[string]interface{}{"email": "alice@example.com"},
+		},
+		&testCredential{
+			issuer:  trusted,
+			subject: credential.AddressKey(alice),
+			claims:  map[string]interface{}{"firstName": "Alice"},
+		},
+	})
+
+	contact, err := client.Contacts().Get(alice.String())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"firstName": "Alice"}, contact.VerifiedClaims)
+}
+
+func TestContactsOnlyFromConnections(t *testing.T) {
+	mallory := testAddress(t)
+	transport := newFakeTransport(t)
+	client, err := New(Config{
+		Transport:        transport.factory(),
+		ConnectionPolicy: ConnectionPolicy{Deny: []string{mallory.String()}},
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	received := make(chan struct{}, 4)
+	client.Chat().OnMessage(func(ChatMessage) {
+		received <- struct{}{}
+	})
+	chat := func(from *signing.PublicKey) {
+		content, err := message.NewChat().Message("hello").Finish()
+		require.NoError(t, err)
+		transport.receive(from, content)
+	}
+	lastInteraction := func(peer *signing.PublicKey) time.Time {
+		contact, err := client.Contacts().Get(peer.String())
+		require.NoError(t, err)
+		return contact.LastInteraction
+	}
+
+	// Messages from strangers do not add contacts
+	stranger := testAddress(t)
+	chat(stranger)
+	<-received
+	_, err = client.Contacts().Get(stranger.String())
+	assert.ErrorIs(t, err, ErrContactNotFound)
+
+	// Messages from contacts update their last interaction
+	alice := testAddress(t)
+	transport.callbacks.OnWelcome(alice, transport.address, nil)
+	established := lastInteraction(alice)
+	time.Sleep(time.Millisecond)
+	chat(alice)
+	<-received
+	assert.True(t, lastInteraction(alice).After(established))
+
+	// Denied peers do not, even when added by hand
+	_, err = client.Contacts().Update(mallory.String(), func(*Contact) {})
+	require.NoError(t, err)
+	chat(mallory)
+	<-received
+	assert.True(t, lastInteraction(mallory).IsZero())
+
+	// Neither do messages rejected by middleware
+	client.Use(func(next MessageHandler) MessageHandler {
+		return func(mc *MessageContext) error {
+			return ErrMessageRejected
+		}
+	})
+	seen := lastInteraction(alice)
+	time.Sleep(time.Millisecond)
+	chat(alice)
+	assert.Equal(t, seen, lastInteraction(alice))
+}
+
+func TestContactVerifiedName(t *testing.T) {
+	contact := &Contact{DID: "did"}
+	_, ok := contact.VerifiedName()
+	assert.False(t, ok)
+
+	contact.VerifiedClaims = map[string]interface{}{
+		"firstName": "Alice",
+		"lastName":  "Smith",
+	}
+	name, ok := contact.VerifiedName()
+	assert.True(t, ok)
+	assert.Equal(t, "Alice Smith", name)
+}
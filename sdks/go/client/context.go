@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// expiresFrom returns the context deadline, or now plus the fallback timeout
+// when the context has no deadline
+func expiresFrom(ctx context.Context, fallback time.Duration) time.Time {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline
+	}
+	return time.Now().Add(fallback)
+}
+
+// runContext runs a blocking transport operation, returning early with the
+// context's error if it is cancelled first. The transport does not support
+// cancellation, so an abandoned operation may still complete in the background.
+func runContext(ctx context.Context, operation func() error) error {
+	_, err := lookupContext(ctx, func() (struct{}, error) {
+		return struct{}{}, operation()
+	})
+	return err
+}
+
+// lookupContext is runContext for operations that return a value
+func lookupContext[T any](ctx context.Context, operation func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	if ctx.Done() == nil {
+		return operation()
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		value, err := operation()
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type traceKey struct{}
+
+func TestExpiresFrom(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	assert.Equal(t, deadline, expiresFrom(ctx, time.Hour))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresFrom(context.Background(), time.Hour), time.Second)
+}
+
+func TestRunContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	release := make(chan struct{})
+	defer close(release)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- runContext(ctx, func() error {
+			<-release
+			return nil
+		})
+	}()
+
+	cancel()
+
+	select {
+	case err := <-result:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("runContext did not return after cancellation")
+	}
+}
+
+func TestSendContext(t *testing.T) {
+	client, transport := newTestClient(t)
+	peer := testAddress(t).String()
+
+	// A cancelled context stops the send before it reaches the transport
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, client.Chat().SendContext(ctx, peer, "hello"), context.Canceled)
+	assert.Equal(t, 0, transport.sentCount())
+
+	// Context values reach outbound middleware
+	var traced interface{}
+	client.Use(func(next MessageHandler) MessageHandler {
+		return func(mc *MessageContext) error {
+			traced = mc.Context.Value(traceKey{})
+			return next(mc)
+		}
+	})
+
+	ctx = context.WithValue(context.Background(), traceKey{}, "trace-1")
+	require.NoError(t, client.Chat().SendContext(ctx, peer, "hello"))
+	assert.Equal(t, "trace-1", traced)
+	assert.Equal(t, 1, transport.sentCount())
+}
+
+func TestStorageContext(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	require.NoError(t, client.Storage().StoreJSONContext(ctx, "key", map[string]string{"a": "b"}))
+
+	var value map[string]string
+	require.NoError(t, client.Storage().LookupJSONContext(ctx, "key", &value))
+	assert.Equal(t, "b", value["a"])
+
+	require.NoError(t, client.Storage().DeleteContext(ctx, "key"))
+	assert.False(t, client.Storage().ExistsContext(ctx, "key"))
+
+	require.NoError(t, client.Storage().StoreTemporaryStringContext(ctx, "name", "alice", time.Minute))
+	name, err := client.Storage().LookupStringContext(ctx, "name")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", name)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, client.Storage().StoreTemporaryJSONContext(cancelled, "key", value, time.Minute), context.Canceled)
+	assert.ErrorIs(t, client.Storage().StoreStringContext(cancelled, "key", "value"), context.Canceled)
+
+	// Namespaces and caches pass the context through
+	namespace := client.Storage().Namespace("user")
+	require.NoError(t, namespace.StoreStringContext(ctx, "name", "bob"))
+	name, err = client.Storage().LookupStringContext(ctx, "user:name")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", name)
+	assert.ErrorIs(t, namespace.DeleteContext(cancelled, "name"), context.Canceled)
+
+	cache := client.Storage().Cache("api")
+	require.NoError(t, cache.SetJSONContext(ctx, "key", map[string]string{"a": "c"}))
+	require.NoError(t, cache.GetJSONContext(ctx, "key", &value))
+	assert.Equal(t, "c", value["a"])
+	assert.True(t, cache.HasContext(ctx, "key"))
+	assert.ErrorIs(t, cache.SetStringContext(cancelled, "key", "value"), context.Canceled)
+}
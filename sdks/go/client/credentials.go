@@ -4,11 +4,9 @@ import (
 	"context"
 	"encoding/hex"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/joinself/self-go-sdk/credential"
-	"github.com/joinself/self-go-sdk/event"
 	"github.com/joinself/self-go-sdk/keypair/signing"
 	"github.com/joinself/self-go-sdk/message"
 	"github.com/joinself/self-go-sdk/object"
@@ -19,7 +17,7 @@ type CredentialRequest struct {
 	client    *Client
 	content   *message.Content
 	requestID string
-	completer chan *CredentialResponse
+	pending   *pendingRequest[*CredentialResponse]
 }
 
 // CredentialResponse represents a credential response
@@ -73,11 +71,10 @@ type Credentials struct {
 	client *Client
 
 	// Event handlers
-	onPresentationRequestHandlers  []func(*IncomingCredentialRequest)
-	onVerificationRequestHandlers  []func(*IncomingCredentialRequest)
-	onPresentationResponseHandlers []func(*CredentialResponse)
-	onVerificationResponseHandlers []func(*CredentialResponse)
-	mu                             sync.RWMutex
+	onPresentationRequestHandlers  handlerList[func(*IncomingCredentialRequest)]
+	onVerificationRequestHandlers  handlerList[func(*IncomingCredentialRequest)]
+	onPresentationResponseHandlers handlerList[func(*CredentialResponse)]
+	onVerificationResponseHandlers handlerList[func(*CredentialResponse)]
 }
 
 // IncomingCredentialRequest represents an incoming credential request
@@ -120,7 +117,7 @@ func (c *Credentials) CreateAsset(name, mimeType string, data []byte) (*Credenti
 	}
 
 	// Upload to object store
-	err = c.client.account.ObjectUpload(obj, false)
+	err = c.client.transport.ObjectUpload(obj, false)
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +136,7 @@ func (c *Credentials) DownloadAsset(asset *CredentialAsset) error {
 		return ErrClientClosed
 	}
 
-	return c.client.account.ObjectDownload(asset.object)
+	return c.client.transport.ObjectDownload(asset.object)
 }
 
 // RequestPresentationWithEvidence requests credential presentations with evidence attachments
@@ -149,6 +146,17 @@ func (c *Credentials) RequestPresentationWithEvidence(peerDID string, details []
 
 // RequestPresentationWithEvidenceAndTimeout requests credential presentations with evidence and custom timeout
 func (c *Credentials) RequestPresentationWithEvidenceAndTimeout(peerDID string, details []*CredentialDetail, evidence []*CredentialEvidence, proof []*credential.VerifiablePresentation, timeout time.Duration) (*CredentialRequest, error) {
+	return c.requestPresentation(context.Background(), peerDID, details, evidence, proof, time.Now().Add(timeout))
+}
+
+// RequestPresentationWithEvidenceContext requests credential presentations with evidence attachments.
+// The request expires at the ctx deadline, or after 5 minutes if ctx has none.
+func (c *Credentials) RequestPresentationWithEvidenceContext(ctx context.Context, peerDID string, details []*CredentialDetail, evidence []*CredentialEvidence, proof []*credential.VerifiablePresentation) (*CredentialRequest, error) {
+	return c.requestPresentation(ctx, peerDID, details, evidence, proof, expiresFrom(ctx, 5*time.Minute))
+}
+
+// requestPresentation builds and sends a credential presentation request
+func (c *Credentials) requestPresentation(ctx context.Context, peerDID string, details []*CredentialDetail, evidence []*CredentialEvidence, proof []*credential.VerifiablePresentation, expires time.Time) (*CredentialRequest, error) {
 	if c.client.isClosed() {
 		return nil, ErrClientClosed
 	}
@@ -162,7 +170,7 @@ func (c *Credentials) RequestPresentationWithEvidenceAndTimeout(peerDID string,
 	// Build the credential presentation request
 	builder := message.NewCredentialPresentationRequest().
 		Type([]string{"VerifiablePresentation", "CustomPresentation"}).
-		Expires(time.Now().Add(timeout))
+		Expires(expires)
 
 	// Add details for each credential type
 	for _, detail := range details {
@@ -188,15 +196,17 @@ func (c *Credentials) RequestPresentationWithEvidenceAndTimeout(peerDID string,
 	}
 
 	requestID := hex.EncodeToString(content.ID())
-	completer := make(chan *CredentialResponse, 1)
+	pending := newPendingRequest[*CredentialResponse](requestID, RequestCredentialPresentation, peerDID, expires)
 
 	// Store request for response tracking
-	c.client.storeRequest(requestID, completer)
+	if err := c.client.trackRequest(pending); err != nil {
+		return nil, err
+	}
 
 	// Send the request
-	err = c.client.sendMessage(peerAddress, *content)
+	err = c.client.sendMessageContext(ctx, peerAddress, *content)
 	if err != nil {
-		c.client.loadAndDeleteRequest(requestID)
+		c.client.cancelRequest(requestID, err)
 		return nil, err
 	}
 
@@ -204,7 +214,7 @@ func (c *Credentials) RequestPresentationWithEvidenceAndTimeout(peerDID string,
 		client:    c.client,
 		content:   content,
 		requestID: requestID,
-		completer: completer,
+		pending:   pending,
 	}
 
 	return req, nil
@@ -217,6 +227,17 @@ func (c *Credentials) RequestVerificationWithEvidence(peerDID string, credential
 
 // RequestVerificationWithEvidenceAndTimeout requests credential verification with evidence and custom timeout
 func (c *Credentials) RequestVerificationWithEvidenceAndTimeout(peerDID string, credentialType []string, evidence []*CredentialEvidence, proof []*credential.VerifiablePresentation, timeout time.Duration) (*CredentialRequest, error) {
+	return c.requestVerification(context.Background(), peerDID, credentialType, evidence, proof, time.Now().Add(timeout))
+}
+
+// RequestVerificationWithEvidenceContext requests credential verification with evidence attachments.
+// The request expires at the ctx deadline, or after 5 minutes if ctx has none.
+func (c *Credentials) RequestVerificationWithEvidenceContext(ctx context.Context, peerDID string, credentialType []string, evidence []*CredentialEvidence, proof []*credential.VerifiablePresentation) (*CredentialRequest, error) {
+	return c.requestVerification(ctx, peerDID, credentialType, evidence, proof, expiresFrom(ctx, 5*time.Minute))
+}
+
+// requestVerification builds and sends a credential verification request
+func (c *Credentials) requestVerification(ctx context.Context, peerDID string, credentialType []string, evidence []*CredentialEvidence, proof []*credential.VerifiablePresentation, expires time.Time) (*CredentialRequest, error) {
 	if c.client.isClosed() {
 		return nil, ErrClientClosed
 	}
@@ -230,7 +251,7 @@ func (c *Credentials) RequestVerificationWithEvidenceAndTimeout(peerDID string,
 	// Build the credential verification request
 	builder := message.NewCredentialVerificationRequest().
 		Type(credentialType).
-		Expires(time.Now().Add(timeout))
+		Expires(expires)
 
 	// Add evidence
 	for _, ev := range evidence {
@@ -248,15 +269,17 @@ func (c *Credentials) RequestVerificationWithEvidenceAndTimeout(peerDID string,
 	}
 
 	requestID := hex.EncodeToString(content.ID())
-	completer := make(chan *CredentialResponse, 1)
+	pending := newPendingRequest[*CredentialResponse](requestID, RequestCredentialVerification, peerDID, expires)
 
 	// Store request for response tracking
-	c.client.storeRequest(requestID, completer)
+	if err := c.client.trackRequest(pending); err != nil {
+		return nil, err
+	}
 
 	// Send the request
-	err = c.client.sendMessage(peerAddress, *content)
+	err = c.client.sendMessageContext(ctx, peerAddress, *content)
 	if err != nil {
-		c.client.loadAndDeleteRequest(requestID)
+		c.client.cancelRequest(requestID, err)
 		return nil, err
 	}
 
@@ -264,7 +287,7 @@ func (c *Credentials) RequestVerificationWithEvidenceAndTimeout(peerDID string,
 		client:    c.client,
 		content:   content,
 		requestID: requestID,
-		completer: completer,
+		pending:   pending,
 	}
 
 	return req, nil
@@ -280,6 +303,12 @@ func (c *Credentials) RequestPresentationWithTimeout(peerDID string, details []*
 	return c.RequestPresentationWithEvidenceAndTimeout(peerDID, details, nil, nil, timeout)
 }
 
+// RequestPresentationContext requests credential presentations from a peer.
+// The request expires at the ctx deadline, or after 5 minutes if ctx has none.
+func (c *Credentials) RequestPresentationContext(ctx context.Context, peerDID string, details []*CredentialDetail) (*CredentialRequest, error) {
+	return c.RequestPresentationWithEvidenceContext(ctx, peerDID, details, nil, nil)
+}
+
 // RequestVerification requests credential verification from a peer
 func (c *Credentials) RequestVerification(peerDID string, credentialType []string) (*CredentialRequest, error) {
 	return c.RequestVerificationWithTimeout(peerDID, credentialType, 5*time.Minute)
@@ -290,32 +319,30 @@ func (c *Credentials) RequestVerificationWithTimeout(peerDID string, credentialT
 	return c.RequestVerificationWithEvidenceAndTimeout(peerDID, credentialType, nil, nil, timeout)
 }
 
+// RequestVerificationContext requests credential verification from a peer.
+// The request expires at the ctx deadline, or after 5 minutes if ctx has none.
+func (c *Credentials) RequestVerificationContext(ctx context.Context, peerDID string, credentialType []string) (*CredentialRequest, error) {
+	return c.RequestVerificationWithEvidenceContext(ctx, peerDID, credentialType, nil, nil)
+}
+
 // OnPresentationRequest registers a handler for incoming credential presentation requests
-func (c *Credentials) OnPresentationRequest(handler func(*IncomingCredentialRequest)) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.onPresentationRequestHandlers = append(c.onPresentationRequestHandlers, handler)
+func (c *Credentials) OnPresentationRequest(handler func(*IncomingCredentialRequest)) Unsubscribe {
+	return c.onPresentationRequestHandlers.add(handler)
 }
 
 // OnVerificationRequest registers a handler for incoming credential verification requests
-func (c *Credentials) OnVerificationRequest(handler func(*IncomingCredentialRequest)) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.onVerificationRequestHandlers = append(c.onVerificationRequestHandlers, handler)
+func (c *Credentials) OnVerificationRequest(handler func(*IncomingCredentialRequest)) Unsubscribe {
+	return c.onVerificationRequestHandlers.add(handler)
 }
 
 // OnPresentationResponse registers a handler for credential presentation responses
-func (c *Credentials) OnPresentationResponse(handler func(*CredentialResponse)) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.onPresentationResponseHandlers = append(c.onPresentationResponseHandlers, handler)
+func (c *Credentials) OnPresentationResponse(handler func(*CredentialResponse)) Unsubscribe {
+	return c.onPresentationResponseHandlers.add(handler)
 }
 
 // OnVerificationResponse registers a handler for credential verification responses
-func (c *Credentials) OnVerificationResponse(handler func(*CredentialResponse)) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.onVerificationResponseHandlers = append(c.onVerificationResponseHandlers, handler)
+func (c *Credentials) OnVerificationResponse(handler func(*CredentialResponse)) Unsubscribe {
+	return c.onVerificationResponseHandlers.add(handler)
 }
 
 // CredentialBuilder methods
@@ -395,19 +422,17 @@ func (b *CredentialBuilder) Issue(client *Client) (*credential.VerifiableCredent
 	}
 
 	// Issue the credential
-	return client.account.CredentialIssue(unsignedCredential)
+	return client.transport.CredentialIssue(unsignedCredential)
 }
 
 // WaitForResponse waits for a response to the credential request
 func (req *CredentialRequest) WaitForResponse(ctx context.Context) (*CredentialResponse, error) {
-	select {
-	case response := <-req.completer:
-		return response, nil
-	case <-ctx.Done():
+	response, err := req.pending.wait(ctx)
+	if err != nil && ctx.Err() != nil {
 		// Clean up the stored request
-		req.client.loadAndDeleteRequest(req.requestID)
-		return nil, ctx.Err()
+		req.client.cancelRequest(req.requestID, err)
 	}
+	return response, err
 }
 
 // RequestID returns the unique identifier for this credential request
@@ -489,7 +514,7 @@ func (req *IncomingCredentialRequest) RespondWithPresentations(presentations []*
 
 	// Build the response
 	builder := message.NewCredentialPresentationResponse().
-		ResponseTo([]byte(req.requestID)).
+		ResponseTo(decodeRequestID(req.requestID)).
 		Status(message.ResponseStatusAccepted)
 
 	for _, presentation := range presentations {
@@ -501,7 +526,7 @@ func (req *IncomingCredentialRequest) RespondWithPresentations(presentations []*
 		return err
 	}
 
-	return req.client.sendMessage(peerAddress, *content)
+	return req.client.outbox.send(context.Background(), OutboxCredentialResponse, peerAddress, *content)
 }
 
 // RespondWithCredentials responds to a verification request with credentials
@@ -518,7 +543,7 @@ func (req *IncomingCredentialRequest) RespondWithCredentials(credentials []*cred
 
 	// Build the response
 	builder := message.NewCredentialVerificationResponse().
-		ResponseTo([]byte(req.requestID)).
+		ResponseTo(decodeRequestID(req.requestID)).
 		Status(message.ResponseStatusAccepted)
 
 	for _, credential := range credentials {
@@ -530,7 +555,7 @@ func (req *IncomingCredentialRequest) RespondWithCredentials(credentials []*cred
 		return err
 	}
 
-	return req.client.sendMessage(peerAddress, *content)
+	return req.client.outbox.send(context.Background(), OutboxCredentialResponse, peerAddress, *content)
 }
 
 // Reject rejects the credential request
@@ -546,12 +571,12 @@ func (req *IncomingCredentialRequest) Reject() error {
 
 	if req.isVerification {
 		content, err = message.NewCredentialVerificationResponse().
-			ResponseTo([]byte(req.requestID)).
+			ResponseTo(decodeRequestID(req.requestID)).
 			Status(message.ResponseStatusForbidden).
 			Finish()
 	} else {
 		content, err = message.NewCredentialPresentationResponse().
-			ResponseTo([]byte(req.requestID)).
+			ResponseTo(decodeRequestID(req.requestID)).
 			Status(message.ResponseStatusForbidden).
 			Finish()
 	}
@@ -560,7 +585,7 @@ func (req *IncomingCredentialRequest) Reject() error {
 		return err
 	}
 
-	return req.client.sendMessage(peerAddress, *content)
+	return req.client.outbox.send(context.Background(), OutboxCredentialResponse, peerAddress, *content)
 }
 
 // CredentialAsset methods
@@ -594,7 +619,7 @@ func (c *Credentials) onWelcome(from *signing.PublicKey, groupAddress *signing.P
 	// New connection established - no specific action needed
 }
 
-func (c *Credentials) onKeyPackage(from *signing.PublicKey) {
+func (c *Credentials) onKeyPackage(from *signing.PublicKey, groupAddress *signing.PublicKey) {
 	// Key package received - no specific action needed
 }
 
@@ -602,10 +627,11 @@ func (c *Credentials) onIntroduction(from *signing.PublicKey, tokenCount int) {
 	// Introduction received - connection is now ready for credential exchange
 }
 
-func (c *Credentials) onCredentialPresentationRequest(msg *event.Message) {
+func (c *Credentials) onCredentialPresentationRequest(msg InboundMessage) {
 	// Decode the credential presentation request
 	presentationRequest, err := message.DecodeCredentialPresentationRequest(msg.Content())
 	if err != nil {
+		c.client.reportMessageError(ComponentCredentials, "DecodeCredentialPresentationRequest", msg, err)
 		return
 	}
 
@@ -640,20 +666,20 @@ func (c *Credentials) onCredentialPresentationRequest(msg *event.Message) {
 	}
 
 	// Notify handlers
-	c.mu.RLock()
-	handlers := make([]func(*IncomingCredentialRequest), len(c.onPresentationRequestHandlers))
-	copy(handlers, c.onPresentationRequestHandlers)
-	c.mu.RUnlock()
+	handlers := c.onPresentationRequestHandlers.snapshot()
 
 	for _, handler := range handlers {
-		go handler(incomingRequest) // Run handlers in goroutines to avoid blocking
+		c.client.runHandler(ComponentCredentials, incomingRequest.from, func() { handler(incomingRequest) })
 	}
+
+	c.client.publish(Event{Type: EventCredentialRequest, PeerDID: incomingRequest.from, CredentialRequest: incomingRequest})
 }
 
-func (c *Credentials) onCredentialVerificationRequest(msg *event.Message) {
+func (c *Credentials) onCredentialVerificationRequest(msg InboundMessage) {
 	// Decode the credential verification request
 	verificationRequest, err := message.DecodeCredentialVerificationRequest(msg.Content())
 	if err != nil {
+		c.client.reportMessageError(ComponentCredentials, "DecodeCredentialVerificationRequest", msg, err)
 		return
 	}
 
@@ -680,33 +706,29 @@ func (c *Credentials) onCredentialVerificationRequest(msg *event.Message) {
 	}
 
 	// Notify handlers
-	c.mu.RLock()
-	handlers := make([]func(*IncomingCredentialRequest), len(c.onVerificationRequestHandlers))
-	copy(handlers, c.onVerificationRequestHandlers)
-	c.mu.RUnlock()
+	handlers := c.onVerificationRequestHandlers.snapshot()
 
 	for _, handler := range handlers {
-		go handler(incomingRequest) // Run handlers in goroutines to avoid blocking
+		c.client.runHandler(ComponentCredentials, incomingRequest.from, func() { handler(incomingRequest) })
 	}
+
+	c.client.publish(Event{Type: EventCredentialRequest, PeerDID: incomingRequest.from, CredentialRequest: incomingRequest})
 }
 
-func (c *Credentials) onCredentialPresentationResponse(msg *event.Message) {
+func (c *Credentials) onCredentialPresentationResponse(msg InboundMessage) {
 	// Decode the credential presentation response
 	presentationResponse, err := message.DecodeCredentialPresentationResponse(msg.Content())
 	if err != nil {
+		c.client.reportMessageError(ComponentCredentials, "DecodeCredentialPresentationResponse", msg, err)
 		return
 	}
 
 	requestID := hex.EncodeToString(presentationResponse.ResponseTo())
 
 	// Find the waiting request
-	completerInterface, ok := c.client.loadAndDeleteRequest(requestID)
-	if !ok {
-		return
-	}
-
-	completer, ok := completerInterface.(chan *CredentialResponse)
-	if !ok {
+	pending, err := resolveRequest[*CredentialResponse](c.client, requestID, msg.FromAddress().String())
+	if err != nil {
+		c.client.reportMessageError(ComponentCredentials, "MatchRequest", msg, err)
 		return
 	}
 
@@ -717,42 +739,37 @@ func (c *Credentials) onCredentialPresentationResponse(msg *event.Message) {
 		presentations: presentationResponse.Presentations(),
 		credentials:   []*credential.VerifiableCredential{},
 	}
+	if response.status == message.ResponseStatusAccepted {
+		c.client.contacts.recordPresentations(response.from, response.presentations)
+	}
 
 	// Send to waiting request
-	select {
-	case completer <- response:
-	default:
-		// Channel full or closed - ignore
-	}
+	pending.complete(response)
 
 	// Notify subscription handlers
-	c.mu.RLock()
-	handlers := make([]func(*CredentialResponse), len(c.onPresentationResponseHandlers))
-	copy(handlers, c.onPresentationResponseHandlers)
-	c.mu.RUnlock()
+	handlers := c.onPresentationResponseHandlers.snapshot()
 
 	for _, handler := range handlers {
-		go handler(response) // Run handlers in goroutines to avoid blocking
+		c.client.runHandler(ComponentCredentials, response.from, func() { handler(response) })
 	}
+
+	c.client.publish(Event{Type: EventCredentialResponse, PeerDID: response.from, CredentialResponse: response})
 }
 
-func (c *Credentials) onCredentialVerificationResponse(msg *event.Message) {
+func (c *Credentials) onCredentialVerificationResponse(msg InboundMessage) {
 	// Decode the credential verification response
 	verificationResponse, err := message.DecodeCredentialVerificationResponse(msg.Content())
 	if err != nil {
+		c.client.reportMessageError(ComponentCredentials, "DecodeCredentialVerificationResponse", msg, err)
 		return
 	}
 
 	requestID := hex.EncodeToString(verificationResponse.ResponseTo())
 
 	// Find the waiting request
-	completerInterface, ok := c.client.loadAndDeleteRequest(requestID)
-	if !ok {
-		return
-	}
-
-	completer, ok := completerInterface.(chan *CredentialResponse)
-	if !ok {
+	pending, err := resolveRequest[*CredentialResponse](c.client, requestID, msg.FromAddress().String())
+	if err != nil {
+		c.client.reportMessageError(ComponentCredentials, "MatchRequest", msg, err)
 		return
 	}
 
@@ -763,30 +780,25 @@ func (c *Credentials) onCredentialVerificationResponse(msg *event.Message) {
 		presentations: []*credential.VerifiablePresentation{},
 		credentials:   verificationResponse.Credentials(),
 	}
+	if response.status == message.ResponseStatusAccepted {
+		c.client.contacts.recordCredentials(response.from, response.credentials)
+	}
 
 	// Send to waiting request
-	select {
-	case completer <- response:
-	default:
-		// Channel full or closed - ignore
-	}
+	pending.complete(response)
 
 	// Notify subscription handlers
-	c.mu.RLock()
-	handlers := make([]func(*CredentialResponse), len(c.onVerificationResponseHandlers))
-	copy(handlers, c.onVerificationResponseHandlers)
-	c.mu.RUnlock()
+	handlers := c.onVerificationResponseHandlers.snapshot()
 
 	for _, handler := range handlers {
-		go handler(response) // Run handlers in goroutines to avoid blocking
+		c.client.runHandler(ComponentCredentials, response.from, func() { handler(response) })
 	}
+
+	c.client.publish(Event{Type: EventCredentialResponse, PeerDID: response.from, CredentialResponse: response})
 }
 
 func (c *Credentials) close() {
-	// Clean up any pending requests
-	// Note: We could iterate through stored requests and close channels,
-	// but the current sync.Map doesn't provide an easy way to do this.
-	// For now, pending requests will timeout naturally.
+	// Pending requests are failed by the client's request registry
 }
 
 // CreatePresentation creates a verifiable presentation from credentials
@@ -811,7 +823,7 @@ func (c *Credentials) CreatePresentation(presentationType []string, credentials
 	}
 
 	// Issue the presentation
-	return c.client.account.PresentationIssue(unsignedPresentation)
+	return c.client.transport.PresentationIssue(unsignedPresentation)
 }
 
 // Send sends a credential to a peer by automatically creating a presentation and sending it
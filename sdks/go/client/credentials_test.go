@@ -0,0 +1,48 @@
+package client
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lastSent returns the content most recently sent through the transport
+func (f *fakeTransport) lastSent(t *testing.T) *message.Content {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	require.NotEmpty(t, f.sent)
+	return f.sent[len(f.sent)-1]
+}
+
+func TestResponsesEchoRequestID(t *testing.T) {
+	client, transport := newTestClient(t)
+	peer := testAddress(t)
+
+	request, err := message.NewChat().
+		Message("request").
+		Finish()
+	require.NoError(t, err)
+	requestID := hex.EncodeToString(request.ID())
+
+	// Credential responses carry the request's message ID, not its hex form
+	presentation := &IncomingCredentialRequest{client: client, from: peer.String(), requestID: requestID}
+	require.NoError(t, presentation.Reject())
+	presentationResponse, err := message.DecodeCredentialPresentationResponse(transport.lastSent(t))
+	require.NoError(t, err)
+	assert.Equal(t, request.ID(), presentationResponse.ResponseTo())
+
+	verification := &IncomingCredentialRequest{client: client, from: peer.String(), requestID: requestID, isVerification: true}
+	require.NoError(t, verification.Reject())
+	verificationResponse, err := message.DecodeCredentialVerificationResponse(transport.lastSent(t))
+	require.NoError(t, err)
+	assert.Equal(t, request.ID(), verificationResponse.ResponseTo())
+
+	pairing := &IncomingPairingRequest{client: client, from: peer.String(), requestID: requestID}
+	require.NoError(t, pairing.Reject())
+	pairingResponse, err := message.DecodeAccountPairingResponse(transport.lastSent(t))
+	require.NoError(t, err)
+	assert.Equal(t, request.ID(), pairingResponse.ResponseTo())
+}
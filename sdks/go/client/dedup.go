@@ -0,0 +1,167 @@
+package client
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// seenMessagesStorageKey is the storage key of the persisted message window
+const seenMessagesStorageKey = "self-client:seen-messages"
+
+// seenMessagesPersistInterval is how often new message IDs are persisted
+var seenMessagesPersistInterval = time.Second
+
+const defaultDedupWindowSize = 10000
+
+// DedupConfig controls inbound message de-duplication. Each message ID is
+// dispatched at most once; the most recent IDs are kept in memory and in
+// encrypted storage so redelivered messages are also dropped after a restart.
+type DedupConfig struct {
+	// Disabled turns off de-duplication
+	Disabled bool
+
+	// WindowSize is the number of recent message IDs remembered (default: 10000)
+	WindowSize int
+}
+
+// messageWindow is a bounded set of recently seen message IDs. When full,
+// the oldest ID is forgotten.
+type messageWindow struct {
+	ids   map[string]struct{}
+	order []string
+	next  int
+	dirty bool
+	mu    sync.Mutex
+}
+
+// newMessageWindow creates a window holding up to size IDs
+func newMessageWindow(size int) *messageWindow {
+	if size <= 0 {
+		size = defaultDedupWindowSize
+	}
+	return &messageWindow{
+		ids:   make(map[string]struct{}, size),
+		order: make([]string, 0, size),
+	}
+}
+
+// add records an ID, reporting false if it was already in the window
+func (w *messageWindow) add(id string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.addLocked(id)
+}
+
+// addLocked records an ID with mu held
+func (w *messageWindow) addLocked(id string) bool {
+	if _, ok := w.ids[id]; ok {
+		return false
+	}
+
+	if len(w.order) < cap(w.order) {
+		w.order = append(w.order, id)
+	} else {
+		delete(w.ids, w.order[w.next])
+		w.order[w.next] = id
+		w.next = (w.next + 1) % len(w.order)
+	}
+	w.ids[id] = struct{}{}
+	w.dirty = true
+	return true
+}
+
+// restore adds persisted IDs, oldest first, without marking the window changed
+func (w *messageWindow) restore(ids []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, id := range ids {
+		w.addLocked(id)
+	}
+	w.dirty = false
+}
+
+// changes returns the IDs in the window, oldest first, if any were added
+// since the last call
+func (w *messageWindow) changes() ([]string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.dirty {
+		return nil, false
+	}
+	w.dirty = false
+
+	ids := make([]string, 0, len(w.order))
+	ids = append(ids, w.order[w.next:]...)
+	ids = append(ids, w.order[:w.next]...)
+	return ids, true
+}
+
+// isDuplicate records an inbound message ID and reports whether the message
+// has been seen before, in this run or a previous one
+func (c *Client) isDuplicate(msg InboundMessage) bool {
+	if c.config.Dedup.Disabled || len(msg.ID()) == 0 {
+		return false
+	}
+	return !c.seenMessages.add(hex.EncodeToString(msg.ID()))
+}
+
+// loadSeenMessages restores the message IDs seen before a restart
+func (c *Client) loadSeenMessages() {
+	if c.config.Dedup.Disabled {
+		return
+	}
+
+	data, err := c.transport.ValueLookup(seenMessagesStorageKey)
+	if err != nil {
+		// Nothing has been received yet
+		return
+	}
+
+	var ids []string
+	if err := json.Unmarshal(data, &ids); err != nil {
+		c.logger.Warn("dropping persisted message IDs", logKeyComponent, ComponentClient, "error", err)
+		return
+	}
+	c.seenMessages.restore(ids)
+}
+
+// persistSeenMessages writes the message window to storage if it changed.
+// It writes through the transport, as it also runs while the client closes.
+// A message whose ID is not persisted yet may be dispatched again if it is
+// redelivered after a crash.
+func (c *Client) persistSeenMessages() {
+	ids, changed := c.seenMessages.changes()
+	if !changed {
+		return
+	}
+
+	data, err := json.Marshal(ids)
+	if err == nil {
+		err = c.transport.ValueStore(seenMessagesStorageKey, data)
+	}
+	if err != nil {
+		c.reportError(ErrorEvent{
+			Component: ComponentClient,
+			Operation: "PersistMessageIDs",
+			Err:       err,
+		})
+	}
+}
+
+// flushSeenMessages periodically persists the message window until the
+// client closes
+func (c *Client) flushSeenMessages() {
+	ticker := time.NewTicker(seenMessagesPersistInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.persistSeenMessages()
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countChats creates a client on the transport that counts received chat messages
+func countChats(t *testing.T, transport *fakeTransport, dedup DedupConfig) (*Client, *atomic.Int32) {
+	client, err := New(Config{Transport: transport.factory(), Dedup: dedup})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	var count atomic.Int32
+	client.Chat().OnMessage(func(ChatMessage) {
+		count.Add(1)
+	})
+	return client, &count
+}
+
+func TestMessageWindow(t *testing.T) {
+	window := newMessageWindow(2)
+
+	assert.True(t, window.add("a"))
+	assert.True(t, window.add("b"))
+	assert.False(t, window.add("a"))
+
+	// The oldest ID is forgotten once the window is full
+	assert.True(t, window.add("c"))
+	assert.False(t, window.add("b"))
+	assert.True(t, window.add("a"))
+
+	// Changes list the window oldest first, once per change
+	ids, changed := window.changes()
+	assert.True(t, changed)
+	assert.Equal(t, []string{"c", "a"}, ids)
+	_, changed = window.changes()
+	assert.False(t, changed)
+
+	restored := newMessageWindow(2)
+	restored.restore([]string{"x", "c", "a"})
+	_, changed = restored.changes()
+	assert.False(t, changed)
+	assert.True(t, restored.add("x"))
+	assert.False(t, restored.add("x"))
+}
+
+func TestDuplicateMessagesDropped(t *testing.T) {
+	transport := newFakeTransport(t)
+	_, count := countChats(t, transport, DedupConfig{})
+
+	content, err := message.NewChat().Message("hello").Finish()
+	require.NoError(t, err)
+
+	peer := testAddress(t)
+	transport.receive(peer, content)
+	transport.receive(peer, content)
+
+	assert.Eventually(t, func() bool { return count.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(1), count.Load())
+}
+
+func TestDuplicateMessagesDroppedAfterRestart(t *testing.T) {
+	transport := newFakeTransport(t)
+	first, count := countChats(t, transport, DedupConfig{})
+
+	content, err := message.NewChat().Message("hello").Finish()
+	require.NoError(t, err)
+
+	peer := testAddress(t)
+	transport.receive(peer, content)
+	assert.Eventually(t, func() bool { return count.Load() == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, first.Close())
+
+	// The seen ID is persisted, so the restarted client drops the redelivery
+	_, count = countChats(t, transport, DedupConfig{})
+	transport.receive(peer, content)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(0), count.Load())
+}
+
+func TestSeenMessagesPersistedUnderOneKey(t *testing.T) {
+	transport := newFakeTransport(t)
+	first, count := countChats(t, transport, DedupConfig{WindowSize: 2})
+
+	peer := testAddress(t)
+	var contents []*message.Content
+	for _, text := range []string{"one", "two", "three"} {
+		content, err := message.NewChat().Message(text).Finish()
+		require.NoError(t, err)
+		contents = append(contents, content)
+		transport.receive(peer, content)
+	}
+	assert.Eventually(t, func() bool { return count.Load() == 3 }, time.Second, time.Millisecond)
+	require.NoError(t, first.Close())
+
+	// Only the most recent IDs are kept
+	data, err := transport.ValueLookup(seenMessagesStorageKey)
+	require.NoError(t, err)
+	var ids []string
+	require.NoError(t, json.Unmarshal(data, &ids))
+	assert.Equal(t, []string{hex.EncodeToString(contents[1].ID()), hex.EncodeToString(contents[2].ID())}, ids)
+
+	// The forgotten message is dispatched again after a restart
+	_, count = countChats(t, transport, DedupConfig{WindowSize: 2})
+	transport.receive(peer, contents[2])
+	transport.receive(peer, contents[0])
+	assert.Eventually(t, func() bool { return count.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(1), count.Load())
+}
+
+func TestDedupDisabled(t *testing.T) {
+	transport := newFakeTransport(t)
+	_, count := countChats(t, transport, DedupConfig{Disabled: true})
+
+	content, err := message.NewChat().Message("hello").Finish()
+	require.NoError(t, err)
+
+	peer := testAddress(t)
+	transport.receive(peer, content)
+	transport.receive(peer, content)
+
+	assert.Eventually(t, func() bool { return count.Load() == 2 }, time.Second, time.Millisecond)
+}
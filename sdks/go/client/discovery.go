@@ -2,27 +2,80 @@ package client
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/hex"
+	"image"
+	"maps"
+	"net/url"
 	"sync"
 	"time"
 
+	"github.com/joinself/self-go-sdk/crypto"
 	"github.com/joinself/self-go-sdk/event"
 	"github.com/joinself/self-go-sdk/keypair/signing"
 	"github.com/joinself/self-go-sdk/message"
 )
 
-// DiscoveryQR represents a QR code for discovery
+// discoveryResponseBuffer caps the buffer of a QR code's Responses channel
+const discoveryResponseBuffer = 64
+
+// deepLinkPayloadParam is the query parameter holding a deep link's request
+const deepLinkPayloadParam = "payload"
+
+// DiscoveryQR represents a QR code for discovery. A QR code accepts
+// responses until it is used up, expires or is revoked.
 type DiscoveryQR struct {
-	client    *Client
+	client   *Client
+	expires  time.Time
+	metadata DiscoveryMetadata
+	pending  *pendingRequest[*Peer]
+
+	// Latest request, issued after each response to a reusable QR code.
+	// Earlier requests stay registered as aliases of the QR code.
 	content   *message.Content
 	requestID string
-	completer chan *Peer
+
+	// Responses and use counts
+	responses chan *Peer
+	maxUses   int
+	uses      int
+	done      bool
+	closed    bool
+	mu        sync.Mutex
+}
+
+// DiscoveryMetadata is application context attached to a discovery QR code.
+// It stays with the client that generated the QR code and is returned on
+// the Peer that responds, so a web session or checkout can be bound to
+// whoever scanned that code. Like the pending request itself, it is only
+// held in memory: a restarted client no longer knows the QR code, and
+// responses to it are reported as unmatched.
+type DiscoveryMetadata struct {
+	// CampaignID identifies the campaign the QR code belongs to
+	CampaignID string
+
+	// SessionID identifies the session that displayed the QR code
+	SessionID string
+
+	// Action is the follow-up action requested once a peer responds
+	Action string
+
+	// Values holds any other application context
+	Values map[string]string
+}
+
+// clone returns a copy of the metadata that does not share its values
+func (m DiscoveryMetadata) clone() DiscoveryMetadata {
+	m.Values = maps.Clone(m.Values)
+	return m
 }
 
 // Peer represents a discovered peer
 type Peer struct {
-	did     string
-	address *signing.PublicKey
+	did       string
+	address   *signing.PublicKey
+	requestID string
+	metadata  DiscoveryMetadata
 }
 
 // Discovery handles peer discovery functionality
@@ -30,8 +83,7 @@ type Discovery struct {
 	client *Client
 
 	// Event handlers
-	onResponseHandlers []func(*Peer)
-	mu                 sync.RWMutex
+	onResponseHandlers handlerList[func(*Peer)]
 }
 
 // newDiscovery creates a new discovery component
@@ -48,61 +100,261 @@ func (d *Discovery) GenerateQR() (*DiscoveryQR, error) {
 
 // GenerateQRWithTimeout creates a discovery QR code with custom timeout
 func (d *Discovery) GenerateQRWithTimeout(timeout time.Duration) (*DiscoveryQR, error) {
-	if d.client.isClosed() {
-		return nil, ErrClientClosed
+	return d.generateQR(context.Background(), time.Now().Add(timeout), 1, DiscoveryMetadata{})
+}
+
+// GenerateQRWithMetadata creates a discovery QR code carrying application
+// metadata, which is returned on the Peer that responds
+func (d *Discovery) GenerateQRWithMetadata(metadata DiscoveryMetadata, timeout time.Duration) (*DiscoveryQR, error) {
+	return d.generateQR(context.Background(), time.Now().Add(timeout), 1, metadata)
+}
+
+// GenerateQRWithMetadataContext creates a discovery QR code carrying
+// application metadata. The QR code expires at the ctx deadline, or after
+// 5 minutes if ctx has none.
+func (d *Discovery) GenerateQRWithMetadataContext(ctx context.Context, metadata DiscoveryMetadata) (*DiscoveryQR, error) {
+	return d.generateQR(ctx, expiresFrom(ctx, 5*time.Minute), 1, metadata)
+}
+
+// GenerateQRContext creates a discovery QR code.
+// The QR code expires at the ctx deadline, or after 5 minutes if ctx has none.
+func (d *Discovery) GenerateQRContext(ctx context.Context) (*DiscoveryQR, error) {
+	return d.generateQR(ctx, expiresFrom(ctx, 5*time.Minute), 1, DiscoveryMetadata{})
+}
+
+// GenerateReusableQR creates a discovery QR code that any number of peers
+// can respond to, for kiosks and other screens. It stops accepting responses
+// after maxUses responses (0 for no limit), once expiry has passed, or when
+// revoked. After each response the QR code carries a fresh request with a
+// new key package, while the requests issued before it, such as the one on
+// a printed copy, keep being accepted.
+func (d *Discovery) GenerateReusableQR(maxUses int, expiry time.Duration) (*DiscoveryQR, error) {
+	return d.GenerateReusableQRContext(context.Background(), maxUses, expiry)
+}
+
+// GenerateReusableQRContext creates a reusable discovery QR code, giving up
+// when ctx is done
+func (d *Discovery) GenerateReusableQRContext(ctx context.Context, maxUses int, expiry time.Duration) (*DiscoveryQR, error) {
+	return d.generateReusableQR(ctx, maxUses, expiry, DiscoveryMetadata{})
+}
+
+// GenerateReusableQRWithMetadata creates a reusable discovery QR code
+// carrying application metadata, such as a campaign ID for a poster
+func (d *Discovery) GenerateReusableQRWithMetadata(metadata DiscoveryMetadata, maxUses int, expiry time.Duration) (*DiscoveryQR, error) {
+	return d.GenerateReusableQRWithMetadataContext(context.Background(), metadata, maxUses, expiry)
+}
+
+// GenerateReusableQRWithMetadataContext creates a reusable discovery QR code
+// carrying application metadata, giving up when ctx is done
+func (d *Discovery) GenerateReusableQRWithMetadataContext(ctx context.Context, metadata DiscoveryMetadata, maxUses int, expiry time.Duration) (*DiscoveryQR, error) {
+	return d.generateReusableQR(ctx, maxUses, expiry, metadata)
+}
+
+// generateReusableQR creates a discovery request accepting up to maxUses
+// responses (0 or less for no limit)
+func (d *Discovery) generateReusableQR(ctx context.Context, maxUses int, expiry time.Duration, metadata DiscoveryMetadata) (*DiscoveryQR, error) {
+	if maxUses < 0 {
+		maxUses = 0
 	}
+	return d.generateQR(ctx, time.Now().Add(expiry), maxUses, metadata)
+}
 
-	// Generate key package for out-of-band negotiation
-	keyPackage, err := d.client.account.ConnectionNegotiateOutOfBand(
-		d.client.inboxAddress,
-		time.Now().Add(timeout),
-	)
-	if err != nil {
-		return nil, err
+// generateQR creates a discovery request that expires at the given time and
+// accepts up to maxUses responses (0 for no limit)
+func (d *Discovery) generateQR(ctx context.Context, expires time.Time, maxUses int, metadata DiscoveryMetadata) (*DiscoveryQR, error) {
+	if d.client.isClosed() {
+		return nil, ErrClientClosed
 	}
 
-	// Build discovery request
-	content, err := message.NewDiscoveryRequest().
-		KeyPackage(keyPackage).
-		Expires(time.Now().Add(timeout)).
-		Finish()
+	content, err := d.newRequest(ctx, expires)
 	if err != nil {
 		return nil, err
 	}
 
 	requestID := hex.EncodeToString(content.ID())
-	completer := make(chan *Peer, 1)
 
-	// Store request for response tracking
-	d.client.storeRequest(requestID, completer)
+	buffer := discoveryResponseBuffer
+	if maxUses > 0 && maxUses < buffer {
+		buffer = maxUses
+	}
 
 	qr := &DiscoveryQR{
 		client:    d.client,
+		expires:   expires,
 		content:   content,
 		requestID: requestID,
-		completer: completer,
+		metadata:  metadata.clone(),
+		pending:   newPendingRequest[*Peer](requestID, RequestDiscovery, "", expires),
+		responses: make(chan *Peer, buffer),
+		maxUses:   maxUses,
+	}
+
+	// Store request for response tracking
+	if err := d.client.trackRequest(qr); err != nil {
+		return nil, err
 	}
 
 	return qr, nil
 }
 
+// newRequest creates a discovery request with a fresh key package for
+// out-of-band negotiation
+func (d *Discovery) newRequest(ctx context.Context, expires time.Time) (*message.Content, error) {
+	keyPackage, err := lookupContext(ctx, func() (*crypto.KeyPackage, error) {
+		return d.client.transport.ConnectionNegotiateOutOfBand(d.client.inboxAddress, expires)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return message.NewDiscoveryRequest().
+		KeyPackage(keyPackage).
+		Expires(expires).
+		Finish()
+}
+
+// rotate issues a reusable QR code a fresh request once a peer has used its
+// key package. The earlier requests stay registered as aliases, so a printed
+// copy of the QR code keeps resolving to it. If no request can be issued,
+// the QR code keeps its current one.
+func (d *Discovery) rotate(qr *DiscoveryQR) {
+	content, err := d.newRequest(context.Background(), qr.expires)
+	if err != nil {
+		d.client.reportError(ErrorEvent{
+			Component: ComponentDiscovery,
+			Operation: "RotateRequest",
+			Err:       err,
+		})
+		return
+	}
+	requestID := hex.EncodeToString(content.ID())
+
+	qr.mu.Lock()
+	if qr.done {
+		qr.mu.Unlock()
+		return
+	}
+	previousID := qr.requestID
+	qr.content = content
+	qr.requestID = requestID
+	qr.mu.Unlock()
+
+	if !d.client.requests.alias(previousID, requestID) {
+		// Revoked, expired or closed while the request was created
+		return
+	}
+	if qr.isDone() {
+		d.client.requests.remove(requestID)
+	}
+}
+
+// Respond answers another client's discovery request the way the Self app
+// does when it scans a QR code: it establishes a connection with the key
+// package in the request and sends a discovery response. qrPayload is a
+// deep link, the Unicode text of a QR code, or the data read from a QR code.
+func (d *Discovery) Respond(qrPayload string) error {
+	return d.RespondContext(context.Background(), qrPayload)
+}
+
+// RespondContext answers a discovery request, giving up when ctx is done
+func (d *Discovery) RespondContext(ctx context.Context, qrPayload string) error {
+	if d.client.isClosed() {
+		return ErrClientClosed
+	}
+
+	anonymousMsg, err := decodeDiscoveryPayload(qrPayload)
+	if err != nil {
+		return err
+	}
+
+	// Sandbox requests can only be answered from the sandbox and vice versa
+	if anonymousMsg.HasFlags(event.MessageFlagTargetSandbox) != (d.client.config.Environment == Sandbox) {
+		return ErrWrongEnvironment
+	}
+
+	return d.respond(ctx, anonymousMsg.Content())
+}
+
+// respond connects with the key package in a discovery request and accepts it
+func (d *Discovery) respond(ctx context.Context, content *message.Content) error {
+	request, err := message.DecodeDiscoveryRequest(content)
+	if err != nil {
+		return ErrInvalidQRCode
+	}
+	if expires := request.Expires(); !expires.IsZero() && time.Now().After(expires) {
+		return ErrRequestExpired
+	}
+
+	groupAddress, err := lookupContext(ctx, func() (*signing.PublicKey, error) {
+		return d.client.transport.ConnectionEstablish(d.client.inboxAddress, request.KeyPackage())
+	})
+	if err != nil {
+		return err
+	}
+	d.client.connection.expectOutOfBand(groupAddress)
+
+	response, err := message.NewDiscoveryResponse().
+		ResponseTo(content.ID()).
+		Status(message.ResponseStatusAccepted).
+		Finish()
+	if err != nil {
+		return err
+	}
+
+	if err := d.client.sendMessageContext(ctx, groupAddress, *response); err != nil {
+		return err
+	}
+
+	d.client.logger.Info("discovery request answered",
+		logKeyComponent, ComponentDiscovery,
+		logKeyRequestID, hex.EncodeToString(content.ID()),
+		"group", groupAddress.String(),
+	)
+	return nil
+}
+
+// decodeDiscoveryPayload returns the discovery request in a deep link, the
+// Unicode text of a QR code, or data read from a QR code
+func decodeDiscoveryPayload(payload string) (*event.AnonymousMessage, error) {
+	if link, err := url.Parse(payload); err == nil && link.Scheme != "" && link.Query().Has(deepLinkPayloadParam) {
+		return DecodeDeepLink(payload)
+	}
+
+	data := []byte(payload)
+	if decoded, err := decodeQRText(payload); err == nil {
+		data = decoded
+	}
+
+	anonymousMsg, err := event.DecodeAnonymousMessage(data)
+	if err != nil || anonymousMsg.Content() == nil {
+		return nil, ErrInvalidQRCode
+	}
+	return anonymousMsg, nil
+}
+
 // OnResponse registers a handler for discovery responses
-func (d *Discovery) OnResponse(handler func(*Peer)) {
-	d.mu.Lock()
-	defer d.mu.Unlock()
-	d.onResponseHandlers = append(d.onResponseHandlers, handler)
+func (d *Discovery) OnResponse(handler func(*Peer)) Unsubscribe {
+	return d.onResponseHandlers.add(handler)
 }
 
-// Unicode returns the QR code as Unicode text
-func (qr *DiscoveryQR) Unicode() (string, error) {
-	anonymousMsg := event.NewAnonymousMessage(qr.content)
+// anonymousMessage returns the message carried by the QR code
+func (qr *DiscoveryQR) anonymousMessage() *event.AnonymousMessage {
+	qr.mu.Lock()
+	content := qr.content
+	qr.mu.Unlock()
+
+	anonymousMsg := event.NewAnonymousMessage(content)
 
 	// Set environment-specific flags
 	if qr.client.config.Environment == Sandbox {
 		anonymousMsg.SetFlags(event.MessageFlagTargetSandbox)
 	}
+	return anonymousMsg
+}
 
-	qrCode, err := anonymousMsg.EncodeToQR(event.QREncodingUnicode)
+// Unicode returns the QR code as Unicode text
+func (qr *DiscoveryQR) Unicode() (string, error) {
+	qrCode, err := qr.anonymousMessage().EncodeToQR(event.QREncodingUnicode)
 	if err != nil {
 		return "", err
 	}
@@ -111,37 +363,203 @@ func (qr *DiscoveryQR) Unicode() (string, error) {
 
 // SVG returns the QR code as SVG
 func (qr *DiscoveryQR) SVG() (string, error) {
-	anonymousMsg := event.NewAnonymousMessage(qr.content)
+	qrCode, err := qr.anonymousMessage().EncodeToQR(event.QREncodingSVG)
+	if err != nil {
+		return "", err
+	}
+	return string(qrCode), nil
+}
 
-	// Set environment-specific flags
-	if qr.client.config.Environment == Sandbox {
-		anonymousMsg.SetFlags(event.MessageFlagTargetSandbox)
+// Image returns the QR code as an image with the default options
+func (qr *DiscoveryQR) Image() (image.Image, error) {
+	return qr.ImageWithOptions(QRImageOptions{})
+}
+
+// ImageWithOptions returns the QR code as an image
+func (qr *DiscoveryQR) ImageWithOptions(options QRImageOptions) (image.Image, error) {
+	data, err := qr.anonymousMessage().Encode()
+	if err != nil {
+		return nil, err
+	}
+	return renderQRImage(data, options)
+}
+
+// PNG returns the QR code as a PNG image of about size pixels square
+func (qr *DiscoveryQR) PNG(size int) ([]byte, error) {
+	return qr.PNGWithOptions(QRImageOptions{Size: size})
+}
+
+// PNGWithOptions returns the QR code as a PNG image
+func (qr *DiscoveryQR) PNGWithOptions(options QRImageOptions) ([]byte, error) {
+	data, err := qr.anonymousMessage().Encode()
+	if err != nil {
+		return nil, err
+	}
+	return renderQRPNG(data, options)
+}
+
+// DeepLink returns a link that opens the discovery request in the Self app,
+// for users on the same device as the QR code. The encoded request is added
+// to baseURL as the payload query parameter.
+func (qr *DiscoveryQR) DeepLink(baseURL string) (string, error) {
+	link, err := url.Parse(baseURL)
+	if err != nil || link.Scheme == "" {
+		return "", ErrInvalidDeepLink
 	}
 
-	qrCode, err := anonymousMsg.EncodeToQR(event.QREncodingSVG)
+	data, err := qr.anonymousMessage().Encode()
 	if err != nil {
 		return "", err
 	}
-	return string(qrCode), nil
+
+	query := link.Query()
+	query.Set(deepLinkPayloadParam, base64.RawURLEncoding.EncodeToString(data))
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+// DecodeDeepLink returns the discovery request in a link created by DeepLink
+func DecodeDeepLink(link string) (*event.AnonymousMessage, error) {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return nil, ErrInvalidDeepLink
+	}
+
+	query := parsed.Query()
+	if !query.Has(deepLinkPayloadParam) {
+		return nil, ErrInvalidDeepLink
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(query.Get(deepLinkPayloadParam))
+	if err != nil {
+		return nil, ErrInvalidDeepLink
+	}
+
+	anonymousMsg, err := event.DecodeAnonymousMessage(data)
+	if err != nil {
+		return nil, ErrInvalidDeepLink
+	}
+	return anonymousMsg, nil
 }
 
-// WaitForResponse waits for someone to scan the QR code and respond
+// WaitForResponse waits for someone to scan the QR code and respond. For a
+// reusable QR code it returns the first response; use Responses for the rest.
 func (qr *DiscoveryQR) WaitForResponse(ctx context.Context) (*Peer, error) {
-	select {
-	case peer := <-qr.completer:
-		return peer, nil
-	case <-ctx.Done():
+	peer, err := qr.pending.wait(ctx)
+	if err != nil && ctx.Err() != nil && qr.maxUses == 1 {
 		// Clean up the stored request
-		qr.client.loadAndDeleteRequest(qr.requestID)
-		return nil, ctx.Err()
+		qr.client.cancelRequest(qr.RequestID(), err)
 	}
+	return peer, err
+}
+
+// Responses returns a channel of the peers that respond to the QR code. It
+// is closed once the QR code is used up, expires or is revoked. Responses
+// that arrive while the channel buffer is full are dropped from the channel
+// but still reach OnResponse handlers and the event stream. A reusable QR
+// code carries its fresh request by the time a peer is received.
+func (qr *DiscoveryQR) Responses() <-chan *Peer {
+	return qr.responses
 }
 
-// RequestID returns the unique identifier for this discovery request
+// Uses returns the number of responses received
+func (qr *DiscoveryQR) Uses() int {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+	return qr.uses
+}
+
+// MaxUses returns the number of responses accepted (0 for no limit)
+func (qr *DiscoveryQR) MaxUses() int {
+	return qr.maxUses
+}
+
+// Revoke stops the QR code accepting responses
+func (qr *DiscoveryQR) Revoke() {
+	qr.fail(ErrDiscoveryRevoked)
+	qr.client.cancelRequest(qr.RequestID(), ErrDiscoveryRevoked)
+}
+
+// Metadata returns the application metadata attached to the QR code
+func (qr *DiscoveryQR) Metadata() DiscoveryMetadata {
+	return qr.metadata.clone()
+}
+
+// RequestID returns the unique identifier for the latest discovery request.
+// It changes after each response to a reusable QR code, and responses to
+// the earlier IDs are still accepted.
 func (qr *DiscoveryQR) RequestID() string {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
 	return qr.requestID
 }
 
+// info describes the QR code by the request ID it was registered under
+func (qr *DiscoveryQR) info() PendingRequest {
+	return qr.pending.info()
+}
+
+// isDone reports whether the QR code has stopped accepting responses
+func (qr *DiscoveryQR) isDone() bool {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+	return qr.done
+}
+
+// fail stops the QR code accepting responses and releases waiters
+func (qr *DiscoveryQR) fail(err error) {
+	qr.mu.Lock()
+	qr.done = true
+	if !qr.closed {
+		qr.closed = true
+		close(qr.responses)
+	}
+	qr.mu.Unlock()
+
+	qr.pending.fail(err)
+}
+
+// use records a response, reporting whether the QR code accepted it and
+// whether it is now used up
+func (qr *DiscoveryQR) use() (accepted, usedUp bool) {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+	if qr.done {
+		return false, false
+	}
+
+	qr.uses++
+	usedUp = qr.maxUses > 0 && qr.uses >= qr.maxUses
+	if usedUp {
+		qr.done = true
+	}
+	return true, usedUp
+}
+
+// deliver passes an accepted response to waiters and the Responses channel,
+// closing the channel once the QR code is used up
+func (qr *DiscoveryQR) deliver(peer *Peer, usedUp bool) {
+	qr.mu.Lock()
+	if !qr.closed {
+		select {
+		case qr.responses <- peer:
+		default:
+			qr.client.logger.Warn("discovery response dropped from full channel",
+				logKeyComponent, ComponentDiscovery,
+				logKeyPeer, peer.did,
+				logKeyRequestID, peer.requestID,
+			)
+		}
+		if usedUp {
+			qr.closed = true
+			close(qr.responses)
+		}
+	}
+	qr.mu.Unlock()
+
+	qr.pending.complete(peer)
+}
+
 // DID returns the peer's decentralized identifier
 func (p *Peer) DID() string {
 	return p.did
@@ -152,6 +570,16 @@ func (p *Peer) Address() *signing.PublicKey {
 	return p.address
 }
 
+// RequestID returns the discovery request the peer responded to
+func (p *Peer) RequestID() string {
+	return p.requestID
+}
+
+// Metadata returns the application metadata of the QR code the peer responded to
+func (p *Peer) Metadata() DiscoveryMetadata {
+	return p.metadata.clone()
+}
+
 // Internal methods for handling events
 
 func (d *Discovery) onConnect() {
@@ -166,7 +594,7 @@ func (d *Discovery) onWelcome(from *signing.PublicKey, groupAddress *signing.Pub
 	// New connection established - no specific action needed
 }
 
-func (d *Discovery) onKeyPackage(from *signing.PublicKey) {
+func (d *Discovery) onKeyPackage(from *signing.PublicKey, groupAddress *signing.PublicKey) {
 	// Key package received - no specific action needed
 }
 
@@ -174,53 +602,59 @@ func (d *Discovery) onIntroduction(from *signing.PublicKey, tokenCount int) {
 	// Introduction received - no specific action needed for discovery
 }
 
-func (d *Discovery) onDiscoveryResponse(msg *event.Message) {
+func (d *Discovery) onDiscoveryResponse(msg InboundMessage) {
 	// Decode the discovery response
 	discoveryResponse, err := message.DecodeDiscoveryResponse(msg.Content())
 	if err != nil {
+		d.client.reportMessageError(ComponentDiscovery, "DecodeDiscoveryResponse", msg, err)
 		return
 	}
 
 	requestID := hex.EncodeToString(discoveryResponse.ResponseTo())
 
-	// Find the waiting request
-	completerInterface, ok := d.client.loadAndDeleteRequest(requestID)
-	if !ok {
+	// Find the QR code the response belongs to
+	request, ok := d.client.requests.lookup(requestID)
+	qr, isQR := request.(*DiscoveryQR)
+	if !ok || !isQR {
+		d.client.reportMessageError(ComponentDiscovery, "MatchRequest", msg, ErrRequestNotFound)
 		return
 	}
 
-	completer, ok := completerInterface.(chan *Peer)
-	if !ok {
-		return
+	// Create peer object carrying the QR code's metadata
+	peer := &Peer{
+		did:       msg.FromAddress().String(),
+		address:   msg.FromAddress(),
+		requestID: requestID,
+		metadata:  qr.metadata.clone(),
 	}
 
-	// Create peer object
-	peer := &Peer{
-		did:     msg.FromAddress().String(),
-		address: msg.FromAddress(),
+	// Record the response
+	accepted, usedUp := qr.use()
+	if usedUp {
+		d.client.requests.remove(requestID)
+	}
+	if !accepted {
+		d.client.reportMessageError(ComponentDiscovery, "MatchRequest", msg, ErrRequestNotFound)
+		return
 	}
 
-	// Send to waiting request
-	select {
-	case completer <- peer:
-	default:
-		// Channel full or closed - ignore
+	// Answer the used key package with a fresh one before the response is
+	// passed on and the QR code is shown again
+	if !usedUp {
+		d.rotate(qr)
 	}
+	qr.deliver(peer, usedUp)
 
 	// Notify subscription handlers
-	d.mu.RLock()
-	handlers := make([]func(*Peer), len(d.onResponseHandlers))
-	copy(handlers, d.onResponseHandlers)
-	d.mu.RUnlock()
+	handlers := d.onResponseHandlers.snapshot()
 
 	for _, handler := range handlers {
-		go handler(peer) // Run handlers in goroutines to avoid blocking
+		d.client.runHandler(ComponentDiscovery, peer.did, func() { handler(peer) })
 	}
+
+	d.client.publish(Event{Type: EventDiscoveryResponse, PeerDID: peer.did, Peer: peer})
 }
 
 func (d *Discovery) close() {
-	// Clean up any pending requests
-	// Note: We could iterate through stored requests and close channels,
-	// but the current sync.Map doesn't provide an easy way to do this.
-	// For now, pending requests will timeout naturally.
+	// Pending requests are failed by the client's request registry
 }
@@ -1,10 +1,18 @@
 package client
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"image/png"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/joinself/self-go-sdk/event"
+	"github.com/joinself/self-go-sdk/keypair/signing"
 	"github.com/joinself/self-go-sdk/message"
+	"github.com/skip2/go-qrcode"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -54,3 +62,290 @@ func createMockChatContent(t *testing.T) *message.Content {
 	require.NoError(t, err)
 	return content
 }
+
+// respondToQR delivers a discovery response to qr's latest request from peer
+func respondToQR(t *testing.T, transport *fakeTransport, qr *DiscoveryQR, peer *signing.PublicKey) {
+	respondToRequest(t, transport, qr.RequestID(), peer)
+}
+
+// respondToRequest delivers a discovery response to a request from peer
+func respondToRequest(t *testing.T, transport *fakeTransport, id string, peer *signing.PublicKey) {
+	requestID, err := hex.DecodeString(id)
+	require.NoError(t, err)
+
+	response, err := message.NewDiscoveryResponse().
+		ResponseTo(requestID).
+		Status(message.ResponseStatusAccepted).
+		Finish()
+	require.NoError(t, err)
+	transport.receive(peer, response)
+}
+
+func TestReusableDiscoveryQR(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	errs := make(chan ErrorEvent, 1)
+	client.OnError(func(event ErrorEvent) {
+		errs <- event
+	})
+
+	qr, err := client.Discovery().GenerateReusableQR(2, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 2, qr.MaxUses())
+
+	first, second, third := testAddress(t), testAddress(t), testAddress(t)
+	firstRequestID := qr.RequestID()
+	respondToQR(t, transport, qr, first)
+
+	// The used key package is replaced before the response is delivered
+	peer := <-qr.Responses()
+	assert.Equal(t, first.String(), peer.DID())
+	assert.Equal(t, firstRequestID, peer.RequestID())
+	assert.NotEqual(t, firstRequestID, qr.RequestID())
+	pending := client.PendingRequests()
+	require.Len(t, pending, 1)
+	assert.Equal(t, firstRequestID, pending[0].ID)
+
+	// Every response is delivered until the QR code is used up
+	respondToQR(t, transport, qr, second)
+	var responded []string
+	for peer := range qr.Responses() {
+		responded = append(responded, peer.DID())
+	}
+	assert.Equal(t, []string{second.String()}, responded)
+	assert.Equal(t, 2, qr.Uses())
+	assert.Empty(t, client.PendingRequests())
+
+	peer, err = qr.WaitForResponse(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, first.String(), peer.DID())
+
+	// Responses after that are rejected
+	respondToQR(t, transport, qr, third)
+	select {
+	case event := <-errs:
+		assert.Equal(t, "MatchRequest", event.Operation)
+		assert.ErrorIs(t, event, ErrRequestNotFound)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for rejected response")
+	}
+	assert.Equal(t, 2, qr.Uses())
+}
+
+func TestReusableDiscoveryQRPrintedCopy(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	qr, err := client.Discovery().GenerateReusableQR(3, time.Hour)
+	require.NoError(t, err)
+
+	// Every peer scanning a printed copy answers the original request
+	printedID := qr.RequestID()
+	for range 2 {
+		respondToRequest(t, transport, printedID, testAddress(t))
+		peer := <-qr.Responses()
+		assert.Equal(t, printedID, peer.RequestID())
+	}
+
+	// The fresh request shown on screen is accepted alongside it
+	shownID := qr.RequestID()
+	assert.NotEqual(t, printedID, shownID)
+	respondToRequest(t, transport, shownID, testAddress(t))
+	peer := <-qr.Responses()
+	assert.Equal(t, shownID, peer.RequestID())
+
+	// Using the QR code up forgets every ID it was issued
+	_, open := <-qr.Responses()
+	assert.False(t, open)
+	assert.Equal(t, 3, qr.Uses())
+	for _, id := range []string{printedID, shownID} {
+		_, ok := client.requests.lookup(id)
+		assert.False(t, ok)
+	}
+}
+
+func TestRevokeDiscoveryQR(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	qr, err := client.Discovery().GenerateReusableQR(0, time.Hour)
+	require.NoError(t, err)
+
+	printedID := qr.RequestID()
+	respondToQR(t, transport, qr, testAddress(t))
+	<-qr.Responses()
+	qr.Revoke()
+	respondToQR(t, transport, qr, testAddress(t))
+	respondToRequest(t, transport, printedID, testAddress(t))
+
+	count := 0
+	for range qr.Responses() {
+		count++
+	}
+	assert.Zero(t, count)
+	assert.Equal(t, 1, qr.Uses())
+	assert.Empty(t, client.PendingRequests())
+
+	// Waiters on an unanswered QR code are released by Revoke
+	unanswered, err := client.Discovery().GenerateQR()
+	require.NoError(t, err)
+	unanswered.Revoke()
+	_, err = unanswered.WaitForResponse(context.Background())
+	assert.ErrorIs(t, err, ErrDiscoveryRevoked)
+}
+
+func TestDiscoveryQRPNG(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	qr, err := client.Discovery().GenerateQR()
+	require.NoError(t, err)
+
+	data, err := qr.PNG(300)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, img.Bounds().Dx(), 300)
+
+	// Options change the quiet zone around the code
+	bare, err := qr.ImageWithOptions(QRImageOptions{Size: 1, NoMargin: true})
+	require.NoError(t, err)
+	padded, err := qr.ImageWithOptions(QRImageOptions{Size: 1, Margin: 2})
+	require.NoError(t, err)
+	assert.Equal(t, bare.Bounds().Dx()+4, padded.Bounds().Dx())
+}
+
+func TestDiscoveryDeepLink(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	qr, err := client.Discovery().GenerateQR()
+	require.NoError(t, err)
+
+	link, err := qr.DeepLink("https://links.example.com/discover?ref=checkout")
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	assert.Equal(t, "links.example.com", parsed.Host)
+	assert.Equal(t, "/discover", parsed.Path)
+	assert.Equal(t, "checkout", parsed.Query().Get("ref"))
+	assert.True(t, parsed.Query().Has(deepLinkPayloadParam))
+
+	_, err = DecodeDeepLink(link)
+	require.NoError(t, err)
+
+	_, err = qr.DeepLink("not a url")
+	assert.ErrorIs(t, err, ErrInvalidDeepLink)
+	_, err = DecodeDeepLink("https://links.example.com/discover")
+	assert.ErrorIs(t, err, ErrInvalidDeepLink)
+	_, err = DecodeDeepLink("https://links.example.com/discover?payload=not+base64")
+	assert.ErrorIs(t, err, ErrInvalidDeepLink)
+}
+
+func TestDiscoveryRespond(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	request, err := message.NewDiscoveryRequest().
+		Expires(time.Now().Add(time.Minute)).
+		Finish()
+	require.NoError(t, err)
+
+	// A response is sent over the connection established from the key package
+	require.NoError(t, client.Discovery().respond(context.Background(), request))
+	require.Equal(t, 1, transport.sentCount())
+	transport.mu.Lock()
+	response, err := message.DecodeDiscoveryResponse(transport.sent[0])
+	transport.mu.Unlock()
+	require.NoError(t, err)
+	assert.Equal(t, request.ID(), response.ResponseTo())
+
+	expired, err := message.NewDiscoveryRequest().
+		Expires(time.Now().Add(-time.Minute)).
+		Finish()
+	require.NoError(t, err)
+	assert.ErrorIs(t, client.Discovery().respond(context.Background(), expired), ErrRequestExpired)
+
+	assert.ErrorIs(t, client.Discovery().Respond("not a discovery request"), ErrInvalidQRCode)
+
+	// Requests for the other environment are refused
+	anonymousMsg := event.NewAnonymousMessage(request)
+	if client.config.Environment != Sandbox {
+		anonymousMsg.SetFlags(event.MessageFlagTargetSandbox)
+	}
+	data, err := anonymousMsg.Encode()
+	require.NoError(t, err)
+	assert.ErrorIs(t, client.Discovery().Respond(string(data)), ErrWrongEnvironment)
+	assert.Equal(t, 1, transport.sentCount())
+
+	// The Unicode text of a QR code is read back into the request
+	anonymousMsg = event.NewAnonymousMessage(request)
+	if client.config.Environment == Sandbox {
+		anonymousMsg.SetFlags(event.MessageFlagTargetSandbox)
+	}
+	data, err = anonymousMsg.Encode()
+	require.NoError(t, err)
+	code, err := qrcode.New(string(data), qrcode.Medium)
+	require.NoError(t, err)
+	require.NoError(t, client.Discovery().Respond(code.ToSmallString(false)))
+	assert.Equal(t, 2, transport.sentCount())
+}
+
+func TestDiscoveryMetadata(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	handled := make(chan *Peer, 1)
+	client.Discovery().OnResponse(func(peer *Peer) {
+		select {
+		case handled <- peer:
+		default:
+		}
+	})
+
+	metadata := DiscoveryMetadata{
+		CampaignID: "spring-sale",
+		SessionID:  "checkout-42",
+		Action:     "confirm-payment",
+		Values:     map[string]string{"basket": "3 items"},
+	}
+	qr, err := client.Discovery().GenerateQRWithMetadata(metadata, time.Minute)
+	require.NoError(t, err)
+
+	// Changing the caller's metadata does not change the QR code's
+	metadata.Values["basket"] = "changed"
+	assert.Equal(t, "3 items", qr.Metadata().Values["basket"])
+
+	responder := testAddress(t)
+	respondToQR(t, transport, qr, responder)
+
+	peer, err := qr.WaitForResponse(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, qr.RequestID(), peer.RequestID())
+	assert.Equal(t, "spring-sale", peer.Metadata().CampaignID)
+	assert.Equal(t, "checkout-42", peer.Metadata().SessionID)
+	assert.Equal(t, "confirm-payment", peer.Metadata().Action)
+	assert.Equal(t, map[string]string{"basket": "3 items"}, peer.Metadata().Values)
+
+	select {
+	case peer := <-handled:
+		assert.Equal(t, "checkout-42", peer.Metadata().SessionID)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for response handler")
+	}
+
+	// QR codes without metadata respond with empty metadata
+	reusable, err := client.Discovery().GenerateReusableQR(1, time.Minute)
+	require.NoError(t, err)
+	respondToQR(t, transport, reusable, responder)
+	peer, err = reusable.WaitForResponse(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, DiscoveryMetadata{}, peer.Metadata())
+
+	// QR codes generated with a context expire at its deadline
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	withContext, err := client.Discovery().GenerateQRWithMetadataContext(ctx, DiscoveryMetadata{SessionID: "login-7"})
+	require.NoError(t, err)
+	assert.Equal(t, "login-7", withContext.Metadata().SessionID)
+	deadline, _ := ctx.Deadline()
+	pending := client.PendingRequests()
+	require.Len(t, pending, 1)
+	assert.Equal(t, withContext.RequestID(), pending[0].ID)
+	assert.Equal(t, deadline, pending[0].ExpiresAt)
+}
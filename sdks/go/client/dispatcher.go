@@ -0,0 +1,234 @@
+package client
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"sync"
+)
+
+// DispatchPolicy decides what happens to handler calls when the queue is full
+type DispatchPolicy int
+
+const (
+	// DispatchBlock makes transport event delivery wait until the queue has
+	// room. Handler calls queued by handlers themselves, or by client methods
+	// they call, are never held back, so a full queue cannot deadlock workers.
+	DispatchBlock DispatchPolicy = iota
+
+	// DispatchDrop discards handler calls that do not fit in the queue
+	DispatchDrop
+)
+
+// DispatchConfig controls how event handlers are run
+type DispatchConfig struct {
+	// Workers is the number of handlers that may run concurrently (default: 16)
+	Workers int
+
+	// QueueSize is the maximum number of queued handler calls (default: 1024)
+	QueueSize int
+
+	// Policy decides what happens when the queue is full (default: DispatchBlock)
+	Policy DispatchPolicy
+}
+
+const (
+	defaultDispatchWorkers   = 16
+	defaultDispatchQueueSize = 1024
+)
+
+// dispatchTask is a queued handler call
+type dispatchTask struct {
+	component string
+	peerDID   string
+	handler   func()
+
+	// errorHandler marks OnError handler calls, whose panics are logged but
+	// not reported again
+	errorHandler bool
+}
+
+// dispatcher runs event handlers on a bounded worker pool. Handler calls that
+// share a key, such as a peer DID, run one at a time in the order they were
+// queued; calls with different keys run concurrently.
+type dispatcher struct {
+	config  DispatchConfig
+	onPanic func(task dispatchTask, recovered interface{}, stack []byte)
+	onDrop  func(component string)
+
+	queues  map[string][]dispatchTask
+	ready   []string
+	pending int
+	unkeyed uint64
+	closed  bool
+
+	mu       sync.Mutex
+	hasWork  *sync.Cond
+	hasSpace *sync.Cond
+}
+
+// newDispatcher creates a dispatcher and starts its workers
+func newDispatcher(config DispatchConfig) *dispatcher {
+	if config.Workers <= 0 {
+		config.Workers = defaultDispatchWorkers
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultDispatchQueueSize
+	}
+
+	d := &dispatcher{
+		config: config,
+		queues: make(map[string][]dispatchTask),
+	}
+	d.hasWork = sync.NewCond(&d.mu)
+	d.hasSpace = sync.NewCond(&d.mu)
+
+	for i := 0; i < config.Workers; i++ {
+		go d.work()
+	}
+	return d
+}
+
+// wait blocks until the queue has room under DispatchBlock. It is called
+// before handling a transport event, never from a worker, so backpressure
+// reaches the connection without stalling handlers that queue more calls.
+func (d *dispatcher) wait() {
+	if d.config.Policy != DispatchBlock {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for !d.closed && d.pending >= d.config.QueueSize {
+		d.hasSpace.Wait()
+	}
+}
+
+// submit queues a handler call. Calls with an empty key are not ordered.
+// It never blocks: under DispatchDrop a call that does not fit is dropped,
+// and under DispatchBlock it is queued anyway since wait already applied
+// backpressure. It reports whether the call was queued.
+func (d *dispatcher) submit(component, key string, handler func()) bool {
+	return d.submitTask(dispatchTask{component: component, peerDID: key, handler: handler})
+}
+
+// submitTask queues a handler call keyed by its peer DID
+func (d *dispatcher) submitTask(task dispatchTask) bool {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return false
+	}
+	if d.pending >= d.config.QueueSize && d.config.Policy == DispatchDrop {
+		d.mu.Unlock()
+		if d.onDrop != nil {
+			d.onDrop(task.component)
+		}
+		return false
+	}
+	defer d.mu.Unlock()
+
+	key := task.peerDID
+	if key == "" {
+		// Unordered calls get a key of their own
+		d.unkeyed++
+		key = "\x00" + strconv.FormatUint(d.unkeyed, 10)
+	}
+
+	queue, active := d.queues[key]
+	d.queues[key] = append(queue, task)
+	d.pending++
+
+	if !active {
+		d.ready = append(d.ready, key)
+		d.hasWork.Signal()
+	}
+	return true
+}
+
+// work runs queued handler calls until the dispatcher is closed and drained
+func (d *dispatcher) work() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for {
+		for len(d.ready) == 0 {
+			if d.closed {
+				return
+			}
+			d.hasWork.Wait()
+		}
+
+		// Take the next call for the first ready key. The key stays in the
+		// queue map while the call runs so later calls for it wait their turn.
+		key := d.ready[0]
+		d.ready = d.ready[1:]
+		task := d.queues[key][0]
+
+		d.mu.Unlock()
+		d.run(task)
+		d.mu.Lock()
+
+		queue := d.queues[key][1:]
+		if len(queue) == 0 {
+			delete(d.queues, key)
+		} else {
+			d.queues[key] = queue
+			d.ready = append(d.ready, key)
+			d.hasWork.Signal()
+		}
+
+		d.pending--
+		d.hasSpace.Signal()
+	}
+}
+
+// run calls a handler, recovering from panics
+func (d *dispatcher) run(task dispatchTask) {
+	defer func() {
+		if r := recover(); r != nil && d.onPanic != nil {
+			d.onPanic(task, r, debug.Stack())
+		}
+	}()
+	task.handler()
+}
+
+// close stops accepting handler calls; queued calls still run
+func (d *dispatcher) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	d.hasWork.Broadcast()
+	d.hasSpace.Broadcast()
+}
+
+// newClientDispatcher creates the client's dispatcher with logging hooks
+func newClientDispatcher(c *Client) *dispatcher {
+	d := newDispatcher(c.config.Dispatch)
+	d.onPanic = func(task dispatchTask, recovered interface{}, stack []byte) {
+		c.logger.Error("handler panicked",
+			logKeyComponent, task.component,
+			logKeyPeer, task.peerDID,
+			"panic", fmt.Sprint(recovered),
+			"stack", string(stack),
+		)
+		if !task.errorHandler {
+			c.reportError(ErrorEvent{
+				Component: task.component,
+				Operation: "Handler",
+				PeerDID:   task.peerDID,
+				Err:       fmt.Errorf("%w: %v", ErrHandlerPanicked, recovered),
+			})
+		}
+	}
+	d.onDrop = func(component string) {
+		c.logger.Warn("handler queue full, dropping event", logKeyComponent, component)
+	}
+	return d
+}
+
+// runHandler queues a user handler on the dispatcher. Handlers with the same
+// peer DID run in order; an empty peer DID means the call is not ordered.
+func (c *Client) runHandler(component, peerDID string, handler func()) {
+	c.dispatcher.submit(component, peerDID, handler)
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcherPerKeyOrder(t *testing.T) {
+	d := newDispatcher(DispatchConfig{Workers: 4})
+	defer d.close()
+
+	var mu sync.Mutex
+	got := make(map[string][]int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		for _, key := range []string{"alice", "bob", "carol"} {
+			wg.Add(1)
+			d.submit(ComponentChat, key, func() {
+				defer wg.Done()
+				mu.Lock()
+				got[key] = append(got[key], i)
+				mu.Unlock()
+			})
+		}
+	}
+	wg.Wait()
+
+	for _, key := range []string{"alice", "bob", "carol"} {
+		require.Len(t, got[key], 100)
+		for i, v := range got[key] {
+			assert.Equal(t, i, v, "handler for %s ran out of order", key)
+		}
+	}
+}
+
+func TestDispatcherRunsKeysConcurrently(t *testing.T) {
+	d := newDispatcher(DispatchConfig{Workers: 2})
+	defer d.close()
+
+	// A blocked handler for one peer does not hold up another peer
+	release := make(chan struct{})
+	d.submit(ComponentChat, "alice", func() { <-release })
+
+	done := make(chan struct{})
+	d.submit(ComponentChat, "bob", func() { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("handler for bob was blocked by alice")
+	}
+	close(release)
+}
+
+func TestDispatcherDropPolicy(t *testing.T) {
+	d := newDispatcher(DispatchConfig{Workers: 1, QueueSize: 2, Policy: DispatchDrop})
+	defer d.close()
+
+	var dropped atomic.Int32
+	d.onDrop = func(component string) {
+		// Drops are reported without holding the queue lock
+		if d.mu.TryLock() {
+			d.mu.Unlock()
+			dropped.Add(1)
+		}
+	}
+
+	release := make(chan struct{})
+	assert.True(t, d.submit(ComponentChat, "alice", func() { <-release }))
+	assert.True(t, d.submit(ComponentChat, "alice", func() {}))
+	assert.False(t, d.submit(ComponentChat, "alice", func() {}))
+	assert.Equal(t, int32(1), dropped.Load())
+	close(release)
+}
+
+func TestDispatcherBlockPolicy(t *testing.T) {
+	d := newDispatcher(DispatchConfig{Workers: 1, QueueSize: 1})
+	defer d.close()
+
+	release := make(chan struct{})
+	d.submit(ComponentChat, "alice", func() { <-release })
+
+	waited := make(chan struct{})
+	go func() {
+		d.wait()
+		close(waited)
+	}()
+
+	select {
+	case <-waited:
+		t.Fatal("wait did not block on a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatal("wait was not released when the queue drained")
+	}
+}
+
+func TestDispatcherSubmitFromWorker(t *testing.T) {
+	d := newDispatcher(DispatchConfig{Workers: 1, QueueSize: 1})
+	defer d.close()
+
+	// A handler queueing more calls on a full queue must not deadlock
+	done := make(chan struct{})
+	d.submit(ComponentChat, "alice", func() {
+		assert.True(t, d.submit(ComponentChat, "alice", func() {}))
+		assert.True(t, d.submit(ComponentChat, "bob", func() { close(done) }))
+	})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("submit from a worker blocked")
+	}
+}
+
+func TestDispatcherRecoversPanics(t *testing.T) {
+	d := newDispatcher(DispatchConfig{Workers: 1})
+	defer d.close()
+
+	panics := make(chan string, 1)
+	d.onPanic = func(task dispatchTask, recovered interface{}, stack []byte) {
+		panics <- fmt.Sprint(recovered)
+	}
+
+	d.submit(ComponentChat, "alice", func() { panic("boom") })
+
+	done := make(chan struct{})
+	d.submit(ComponentChat, "alice", func() { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not survive a panicking handler")
+	}
+	assert.Equal(t, "boom", <-panics)
+}
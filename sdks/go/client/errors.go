@@ -14,12 +14,92 @@ var (
 	// Discovery errors
 	ErrDiscoveryTimeout = errors.New("discovery request timed out")
 	ErrInvalidQRCode    = errors.New("invalid QR code")
+	ErrDiscoveryRevoked = errors.New("discovery request revoked")
+	ErrQRCodeTooLarge   = errors.New("data too large for QR code")
+	ErrInvalidDeepLink  = errors.New("invalid deep link")
+	ErrWrongEnvironment = errors.New("discovery request is for a different environment")
 
 	// Chat errors
 	ErrInvalidPeerDID  = errors.New("invalid peer DID")
 	ErrMessageTooLarge = errors.New("message too large")
 
+	// Connection errors
+	ErrNotConnected             = errors.New("not connected to peer")
+	ErrPeerDisconnected         = errors.New("peer disconnected")
+	ErrTokenRemovalNotSupported = errors.New("transport cannot remove peer tokens")
+
 	// Request errors
 	ErrRequestNotFound = errors.New("request not found")
 	ErrInvalidResponse = errors.New("invalid response")
+	ErrRequestExpired  = errors.New("request expired")
+
+	// Connection policy errors
+	ErrPeerDenied         = errors.New("peer is denied by connection policy")
+	ErrPeerNotAllowed     = errors.New("peer is not on the connection allowlist")
+	ErrPeerNotApproved    = errors.New("peer was not approved")
+	ErrTooManyConnections = errors.New("too many connections")
+
+	// Contact errors
+	ErrContactNotFound = errors.New("contact not found")
+
+	// Outbox errors
+	ErrOutboxFull   = errors.New("outbox is full")
+	ErrOutboxGaveUp = errors.New("outbox gave up sending")
+
+	// Middleware errors
+	ErrMessageRejected = errors.New("message rejected by middleware")
+
+	// Handler errors
+	ErrHandlerPanicked = errors.New("handler panicked")
+)
+
+// Component names reported in error events
+const (
+	ComponentClient        = "client"
+	ComponentDiscovery     = "discovery"
+	ComponentChat          = "chat"
+	ComponentCredentials   = "credentials"
+	ComponentGroupChats    = "groupchats"
+	ComponentNotifications = "notifications"
+	ComponentStorage       = "storage"
+	ComponentPairing       = "pairing"
+	ComponentConnection    = "connection"
+	ComponentOutbox        = "outbox"
+	ComponentContacts      = "contacts"
 )
+
+// ErrorEvent describes an error that occurred while handling an event in the
+// background, where it cannot be returned to a caller
+type ErrorEvent struct {
+	// Component is the component that encountered the error
+	Component string
+
+	// Operation is the operation that failed
+	Operation string
+
+	// PeerDID is the peer involved, if any
+	PeerDID string
+
+	// MessageID is the hex encoded ID of the message being handled, if any
+	MessageID string
+
+	// Err is the underlying error
+	Err error
+}
+
+// Error returns a description of the error event
+func (e ErrorEvent) Error() string {
+	msg := e.Component + ": " + e.Operation
+	if e.PeerDID != "" {
+		msg += " (peer " + e.PeerDID + ")"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error
+func (e ErrorEvent) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorEvent(t *testing.T) {
+	cause := errors.New("boom")
+	event := ErrorEvent{
+		Component: ComponentChat,
+		Operation: "DecodeChat",
+		PeerDID:   "did:peer",
+		Err:       cause,
+	}
+
+	assert.ErrorIs(t, event, cause)
+	assert.Equal(t, "chat: DecodeChat (peer did:peer): boom", event.Error())
+}
+
+func TestOnErrorReportsUnmatchedResponse(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	events := make(chan ErrorEvent, 1)
+	client.OnError(func(event ErrorEvent) {
+		events <- event
+	})
+
+	response, err := message.NewDiscoveryResponse().
+		ResponseTo([]byte("unknown")).
+		Status(message.ResponseStatusAccepted).
+		Finish()
+	require.NoError(t, err)
+
+	peer := testAddress(t)
+	transport.receive(peer, response)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, ComponentDiscovery, event.Component)
+		assert.Equal(t, "MatchRequest", event.Operation)
+		assert.Equal(t, peer.String(), event.PeerDID)
+		assert.Equal(t, hex.EncodeToString(response.ID()), event.MessageID)
+		assert.ErrorIs(t, event, ErrRequestNotFound)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error event")
+	}
+}
+
+func TestOnErrorReportsMiddlewareRejection(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	events := make(chan ErrorEvent, 1)
+	client.OnError(func(event ErrorEvent) {
+		events <- event
+	})
+
+	client.Use(func(next MessageHandler) MessageHandler {
+		return func(mc *MessageContext) error {
+			return ErrMessageRejected
+		}
+	})
+
+	content, err := message.NewChat().Message("hello").Finish()
+	require.NoError(t, err)
+	transport.receive(testAddress(t), content)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, ComponentClient, event.Component)
+		assert.ErrorIs(t, event, ErrMessageRejected)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error event")
+	}
+}
+
+func TestOnErrorReportsHandlerPanic(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	events := make(chan ErrorEvent, 2)
+	client.OnError(func(event ErrorEvent) {
+		events <- event
+		panic("error handler failure")
+	})
+
+	stream := client.Events(context.Background(), EventFilter{Types: []EventType{EventError}})
+
+	client.Chat().OnMessage(func(msg ChatMessage) {
+		panic("handler failure")
+	})
+
+	content, err := message.NewChat().Message("hello").Finish()
+	require.NoError(t, err)
+
+	peer := testAddress(t)
+	transport.receive(peer, content)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, ComponentChat, event.Component)
+		assert.Equal(t, "Handler", event.Operation)
+		assert.Equal(t, peer.String(), event.PeerDID)
+		assert.ErrorIs(t, event, ErrHandlerPanicked)
+		assert.Contains(t, event.Error(), "handler failure")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error event")
+	}
+
+	select {
+	case event := <-stream:
+		require.NotNil(t, event.Error)
+		assert.ErrorIs(t, event.Error, ErrHandlerPanicked)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error stream event")
+	}
+
+	// A panicking error handler is not reported again
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected error event: %v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,237 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// eventBufferSize is the channel buffer of each event stream
+	eventBufferSize = 64
+
+	// eventQueueSize is the number of events a stream holds for a slow consumer
+	eventQueueSize = 1024
+)
+
+// EventType identifies the kind of an Event
+type EventType int
+
+const (
+	EventChatMessage EventType = iota
+	EventGroupMessage
+	EventGroupInvite
+	EventDiscoveryResponse
+	EventCredentialRequest
+	EventCredentialResponse
+	EventPairingRequest
+	EventPairingResponse
+	EventConnected
+	EventDisconnected
+	EventError
+	EventOutboxDelivered
+	EventOutboxGaveUp
+	EventConnectionRejected
+	EventPeerConnected
+	EventPeerDisconnected
+	EventPeerRemoved
+)
+
+// String returns the event type name
+func (t EventType) String() string {
+	switch t {
+	case EventChatMessage:
+		return "chat_message"
+	case EventGroupMessage:
+		return "group_message"
+	case EventGroupInvite:
+		return "group_invite"
+	case EventDiscoveryResponse:
+		return "discovery_response"
+	case EventCredentialRequest:
+		return "credential_request"
+	case EventCredentialResponse:
+		return "credential_response"
+	case EventPairingRequest:
+		return "pairing_request"
+	case EventPairingResponse:
+		return "pairing_response"
+	case EventConnected:
+		return "connected"
+	case EventDisconnected:
+		return "disconnected"
+	case EventError:
+		return "error"
+	case EventOutboxDelivered:
+		return "outbox_delivered"
+	case EventOutboxGaveUp:
+		return "outbox_gave_up"
+	case EventConnectionRejected:
+		return "connection_rejected"
+	case EventPeerConnected:
+		return "peer_connected"
+	case EventPeerDisconnected:
+		return "peer_disconnected"
+	case EventPeerRemoved:
+		return "peer_removed"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a tagged union of everything the client reports. Type says which
+// of the payload fields is set.
+type Event struct {
+	Type EventType
+	Time time.Time
+
+	// PeerDID is the peer the event relates to, if any
+	PeerDID string
+
+	ChatMessage        *ChatMessage               // EventChatMessage
+	GroupMessage       *GroupChatMessage          // EventGroupMessage
+	GroupInvite        *GroupChatInvitation       // EventGroupInvite
+	Peer               *Peer                      // EventDiscoveryResponse
+	CredentialRequest  *IncomingCredentialRequest // EventCredentialRequest
+	CredentialResponse *CredentialResponse        // EventCredentialResponse
+	PairingRequest     *IncomingPairingRequest    // EventPairingRequest
+	PairingResponse    *PairingResponse           // EventPairingResponse
+	Error              *ErrorEvent                // EventError
+	OutboxEntry        *OutboxEntry               // EventOutboxDelivered, EventOutboxGaveUp
+	Rejection          *ConnectionRejection       // EventConnectionRejected
+	PeerInfo           *PeerInfo                  // EventPeerConnected, EventPeerDisconnected, EventPeerRemoved
+
+	// Cause is the reason for an EventDisconnected, EventOutboxGaveUp or
+	// EventConnectionRejected, if known
+	Cause error
+}
+
+// EventFilter selects the events delivered by Client.Events.
+// The zero value matches every event.
+type EventFilter struct {
+	// Types limits the stream to the given event types
+	Types []EventType
+
+	// PeerDID limits the stream to events relating to a single peer
+	PeerDID string
+}
+
+// Matches reports whether the event passes the filter
+func (f EventFilter) Matches(event Event) bool {
+	if f.PeerDID != "" && f.PeerDID != event.PeerDID {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == event.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// eventStream is a subscriber of Client.Events. Published events wait in the
+// stream's own queue and a goroutine per stream hands them to the consumer.
+type eventStream struct {
+	filter EventFilter
+	events chan Event
+	queue  []Event
+	wake   chan struct{}
+	mu     sync.Mutex
+}
+
+// Events returns a channel of client events matching the filter. The channel
+// is closed when ctx is done or the client is closed. Events arrive in the
+// order they were published. Each stream buffers its own events, so a slow
+// consumer holds up neither handlers nor other streams; once eventQueueSize
+// events are waiting, further events for the stream are dropped and logged.
+func (c *Client) Events(ctx context.Context, filter EventFilter) <-chan Event {
+	stream := &eventStream{
+		filter: filter,
+		events: make(chan Event, eventBufferSize),
+		wake:   make(chan struct{}, 1),
+	}
+
+	if c.isClosed() {
+		close(stream.events)
+		return stream.events
+	}
+
+	unsubscribe := c.eventStreams.add(stream)
+
+	go func() {
+		stream.run(ctx, c.done)
+		unsubscribe()
+		close(stream.events)
+	}()
+
+	return stream.events
+}
+
+// push queues an event for the consumer. It reports false when the queue is full.
+func (s *eventStream) push(event Event) bool {
+	s.mu.Lock()
+	if len(s.queue) >= eventQueueSize {
+		s.mu.Unlock()
+		return false
+	}
+	s.queue = append(s.queue, event)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// run hands queued events to the consumer until ctx or done is closed
+func (s *eventStream) run(ctx context.Context, done <-chan struct{}) {
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			select {
+			case <-s.wake:
+				continue
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			}
+		}
+		event := s.queue[0]
+		s.queue[0] = Event{}
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		select {
+		case s.events <- event:
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+// publish queues an event on every matching event stream
+func (c *Client) publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	for _, stream := range c.eventStreams.snapshot() {
+		if !stream.filter.Matches(event) {
+			continue
+		}
+		if !stream.push(event) {
+			c.logger.Warn("event stream full, dropping event",
+				logKeyPeer, event.PeerDID,
+				"event", event.Type.String(),
+			)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/joinself/self-go-sdk/keypair/signing"
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nextEvent reads an event from the stream or fails the test
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "event stream closed")
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+		return Event{}
+	}
+}
+
+func TestEventsStream(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := client.Events(ctx, EventFilter{})
+
+	peer := testAddress(t)
+	for _, text := range []string{"one", "two", "three"} {
+		content, err := message.NewChat().Message(text).Finish()
+		require.NoError(t, err)
+		transport.receive(peer, content)
+	}
+
+	// Events from a peer arrive in order
+	for _, text := range []string{"one", "two", "three"} {
+		event := nextEvent(t, events)
+		assert.Equal(t, EventChatMessage, event.Type)
+		assert.Equal(t, peer.String(), event.PeerDID)
+		require.NotNil(t, event.ChatMessage)
+		assert.Equal(t, text, event.ChatMessage.Text())
+	}
+
+	transport.callbacks.OnDisconnect(nil)
+	event := nextEvent(t, events)
+	assert.Equal(t, EventDisconnected, event.Type)
+
+	// The stream closes when the context is cancelled
+	cancel()
+	assert.Eventually(t, func() bool {
+		select {
+		case _, ok := <-events:
+			return !ok
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestEventsFilter(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	alice := testAddress(t)
+	bob := testAddress(t)
+
+	events := client.Events(ctx, EventFilter{
+		Types:   []EventType{EventChatMessage},
+		PeerDID: bob.String(),
+	})
+
+	for _, from := range []*signing.PublicKey{alice, bob} {
+		content, err := message.NewChat().Message("hello").Finish()
+		require.NoError(t, err)
+		transport.receive(from, content)
+	}
+	transport.callbacks.OnConnect()
+
+	event := nextEvent(t, events)
+	assert.Equal(t, EventChatMessage, event.Type)
+	assert.Equal(t, bob.String(), event.PeerDID)
+
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event %s", event.Type)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestEventsClosedWithClient(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	events := client.Events(context.Background(), EventFilter{})
+	require.NoError(t, client.Close())
+
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("event stream not closed with client")
+	}
+}
+
+func TestEventsOrderWithoutPeer(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := client.Events(ctx, EventFilter{})
+
+	// Connection events relate to no peer but still arrive in order
+	for i := 0; i < 200; i++ {
+		eventType := EventConnected
+		if i%2 == 1 {
+			eventType = EventDisconnected
+		}
+		client.publish(Event{Type: eventType})
+	}
+	for i := 0; i < 200; i++ {
+		event := nextEvent(t, events)
+		if i%2 == 0 {
+			assert.Equal(t, EventConnected, event.Type, "event %d", i)
+		} else {
+			assert.Equal(t, EventDisconnected, event.Type, "event %d", i)
+		}
+	}
+}
+
+func TestEventsSlowConsumer(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stalled := client.Events(ctx, EventFilter{})
+	active := client.Events(ctx, EventFilter{Types: []EventType{EventError}})
+
+	// A stream nobody reads drops events instead of blocking publishers
+	published := make(chan struct{})
+	go func() {
+		for i := 0; i < eventBufferSize+eventQueueSize+10; i++ {
+			client.publish(Event{Type: EventConnected})
+		}
+		client.publish(Event{Type: EventError})
+		close(published)
+	}()
+
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("publish blocked on a stalled stream")
+	}
+	assert.Equal(t, EventError, nextEvent(t, active).Type)
+	assert.Equal(t, EventConnected, nextEvent(t, stalled).Type)
+}
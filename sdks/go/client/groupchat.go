@@ -5,7 +5,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/joinself/self-go-sdk/event"
 	"github.com/joinself/self-go-sdk/keypair/signing"
 	"github.com/joinself/self-go-sdk/message"
 )
@@ -13,6 +12,7 @@ import (
 // GroupChatMessage represents a received group chat message
 type GroupChatMessage struct {
 	from        string
+	fromName    string
 	text        string
 	id          string
 	refID       string
@@ -73,13 +73,12 @@ type GroupChats struct {
 	mu     sync.RWMutex
 
 	// Event handlers
-	onGroupMessageHandlers []func(GroupChatMessage)
-	onGroupInviteHandlers  []func(*GroupChatInvitation)
-	onMemberJoinedHandlers []func(groupID string, member *GroupMember)
-	onMemberLeftHandlers   []func(groupID string, memberDID string)
-	onGroupCreatedHandlers []func(*GroupChat)
-	onGroupUpdatedHandlers []func(*GroupChat)
-	handlerMu              sync.RWMutex
+	onGroupMessageHandlers handlerList[func(GroupChatMessage)]
+	onGroupInviteHandlers  handlerList[func(*GroupChatInvitation)]
+	onMemberJoinedHandlers handlerList[func(groupID string, member *GroupMember)]
+	onMemberLeftHandlers   handlerList[func(groupID string, memberDID string)]
+	onGroupCreatedHandlers handlerList[func(*GroupChat)]
+	onGroupUpdatedHandlers handlerList[func(*GroupChat)]
 }
 
 // newGroupChats creates a new group chats component
@@ -123,13 +122,10 @@ func (gc *GroupChats) CreateGroup(name, description string) (*GroupChat, error)
 	gc.mu.Unlock()
 
 	// Notify handlers
-	gc.handlerMu.RLock()
-	handlers := make([]func(*GroupChat), len(gc.onGroupCreatedHandlers))
-	copy(handlers, gc.onGroupCreatedHandlers)
-	gc.handlerMu.RUnlock()
+	handlers := gc.onGroupCreatedHandlers.snapshot()
 
 	for _, handler := range handlers {
-		go handler(group)
+		gc.client.runHandler(ComponentGroupChats, "", func() { handler(group) })
 	}
 
 	return group, nil
@@ -224,13 +220,11 @@ func (gc *GroupChats) JoinGroup(invitation *GroupChatInvitation) error {
 	}
 
 	// Notify handlers
-	gc.handlerMu.RLock()
-	handlers := make([]func(groupID string, member *GroupMember), len(gc.onMemberJoinedHandlers))
-	copy(handlers, gc.onMemberJoinedHandlers)
-	gc.handlerMu.RUnlock()
+	handlers := gc.onMemberJoinedHandlers.snapshot()
 
+	member := group.members[gc.client.DID()]
 	for _, handler := range handlers {
-		go handler(invitation.GroupID, group.members[gc.client.DID()])
+		gc.client.runHandler(ComponentGroupChats, "", func() { handler(invitation.GroupID, member) })
 	}
 
 	return nil
@@ -364,13 +358,11 @@ func (gc *GroupChats) LeaveGroup(groupID string) error {
 	gc.mu.Unlock()
 
 	// Notify handlers
-	gc.handlerMu.RLock()
-	handlers := make([]func(groupID string, memberDID string), len(gc.onMemberLeftHandlers))
-	copy(handlers, gc.onMemberLeftHandlers)
-	gc.handlerMu.RUnlock()
+	handlers := gc.onMemberLeftHandlers.snapshot()
 
+	memberDID := gc.client.DID()
 	for _, handler := range handlers {
-		go handler(groupID, gc.client.DID())
+		gc.client.runHandler(ComponentGroupChats, "", func() { handler(groupID, memberDID) })
 	}
 
 	return nil
@@ -379,45 +371,33 @@ func (gc *GroupChats) LeaveGroup(groupID string) error {
 // Event handler registration methods
 
 // OnGroupMessage registers a handler for incoming group messages
-func (gc *GroupChats) OnGroupMessage(handler func(GroupChatMessage)) {
-	gc.handlerMu.Lock()
-	defer gc.handlerMu.Unlock()
-	gc.onGroupMessageHandlers = append(gc.onGroupMessageHandlers, handler)
+func (gc *GroupChats) OnGroupMessage(handler func(GroupChatMessage)) Unsubscribe {
+	return gc.onGroupMessageHandlers.add(handler)
 }
 
 // OnGroupInvite registers a handler for group invitations
-func (gc *GroupChats) OnGroupInvite(handler func(*GroupChatInvitation)) {
-	gc.handlerMu.Lock()
-	defer gc.handlerMu.Unlock()
-	gc.onGroupInviteHandlers = append(gc.onGroupInviteHandlers, handler)
+func (gc *GroupChats) OnGroupInvite(handler func(*GroupChatInvitation)) Unsubscribe {
+	return gc.onGroupInviteHandlers.add(handler)
 }
 
 // OnMemberJoined registers a handler for when a member joins a group
-func (gc *GroupChats) OnMemberJoined(handler func(groupID string, member *GroupMember)) {
-	gc.handlerMu.Lock()
-	defer gc.handlerMu.Unlock()
-	gc.onMemberJoinedHandlers = append(gc.onMemberJoinedHandlers, handler)
+func (gc *GroupChats) OnMemberJoined(handler func(groupID string, member *GroupMember)) Unsubscribe {
+	return gc.onMemberJoinedHandlers.add(handler)
 }
 
 // OnMemberLeft registers a handler for when a member leaves a group
-func (gc *GroupChats) OnMemberLeft(handler func(groupID string, memberDID string)) {
-	gc.handlerMu.Lock()
-	defer gc.handlerMu.Unlock()
-	gc.onMemberLeftHandlers = append(gc.onMemberLeftHandlers, handler)
+func (gc *GroupChats) OnMemberLeft(handler func(groupID string, memberDID string)) Unsubscribe {
+	return gc.onMemberLeftHandlers.add(handler)
 }
 
 // OnGroupCreated registers a handler for when a group is created
-func (gc *GroupChats) OnGroupCreated(handler func(*GroupChat)) {
-	gc.handlerMu.Lock()
-	defer gc.handlerMu.Unlock()
-	gc.onGroupCreatedHandlers = append(gc.onGroupCreatedHandlers, handler)
+func (gc *GroupChats) OnGroupCreated(handler func(*GroupChat)) Unsubscribe {
+	return gc.onGroupCreatedHandlers.add(handler)
 }
 
 // OnGroupUpdated registers a handler for when a group is updated
-func (gc *GroupChats) OnGroupUpdated(handler func(*GroupChat)) {
-	gc.handlerMu.Lock()
-	defer gc.handlerMu.Unlock()
-	gc.onGroupUpdatedHandlers = append(gc.onGroupUpdatedHandlers, handler)
+func (gc *GroupChats) OnGroupUpdated(handler func(*GroupChat)) Unsubscribe {
+	return gc.onGroupUpdatedHandlers.add(handler)
 }
 
 // GroupChat methods
@@ -524,6 +504,11 @@ func (m GroupChatMessage) From() string {
 	return m.from
 }
 
+// FromName returns the sender's display name from the contact book
+func (m GroupChatMessage) FromName() string {
+	return m.fromName
+}
+
 // Text returns the message text
 func (m GroupChatMessage) Text() string {
 	return m.text
@@ -624,7 +609,7 @@ func (gc *GroupChats) onWelcome(from *signing.PublicKey, groupAddress *signing.P
 	}
 }
 
-func (gc *GroupChats) onKeyPackage(from *signing.PublicKey) {
+func (gc *GroupChats) onKeyPackage(from *signing.PublicKey, groupAddress *signing.PublicKey) {
 	// Key package received - no specific action needed
 }
 
@@ -632,10 +617,11 @@ func (gc *GroupChats) onIntroduction(from *signing.PublicKey, tokenCount int) {
 	// Introduction received - connection is now ready for group chat
 }
 
-func (gc *GroupChats) onChatMessage(msg *event.Message) {
+func (gc *GroupChats) onChatMessage(msg InboundMessage) {
 	// Decode the chat message
 	chat, err := message.DecodeChat(msg.Content())
 	if err != nil {
+		gc.client.reportMessageError(ComponentGroupChats, "DecodeChat", msg, err)
 		return
 	}
 
@@ -680,6 +666,7 @@ func (gc *GroupChats) onChatMessage(msg *event.Message) {
 				// Create group chat message
 				groupMessage := GroupChatMessage{
 					from:        fromDID,
+					fromName:    gc.client.contacts.DisplayName(fromDID),
 					text:        actualMessage,
 					id:          string(msg.ID()),
 					refID:       string(chat.Referencing()),
@@ -690,14 +677,13 @@ func (gc *GroupChats) onChatMessage(msg *event.Message) {
 				}
 
 				// Notify handlers
-				gc.handlerMu.RLock()
-				handlers := make([]func(GroupChatMessage), len(gc.onGroupMessageHandlers))
-				copy(handlers, gc.onGroupMessageHandlers)
-				gc.handlerMu.RUnlock()
+				handlers := gc.onGroupMessageHandlers.snapshot()
 
 				for _, handler := range handlers {
-					go handler(groupMessage)
+					gc.client.runHandler(ComponentGroupChats, fromDID, func() { handler(groupMessage) })
 				}
+
+				gc.client.publish(Event{Type: EventGroupMessage, PeerDID: fromDID, GroupMessage: &groupMessage})
 				return
 			}
 		}
@@ -711,20 +697,43 @@ func (gc *GroupChats) onChatMessage(msg *event.Message) {
 			GroupID:     generateGroupID(), // Simplified - should come from message metadata
 			GroupName:   "Invited Group",   // Simplified - should come from message metadata
 			InviterDID:  fromDID,
-			InviterName: fromDID,          // Could be enhanced with actual names
+			InviterName: gc.client.contacts.DisplayName(fromDID),
 			Message:     messageText[18:], // Skip "Group Invitation: "
 			ExpiresAt:   time.Now().Add(7 * 24 * time.Hour),
 			client:      gc.client,
 		}
 
 		// Notify handlers
-		gc.handlerMu.RLock()
-		handlers := make([]func(*GroupChatInvitation), len(gc.onGroupInviteHandlers))
-		copy(handlers, gc.onGroupInviteHandlers)
-		gc.handlerMu.RUnlock()
+		handlers := gc.onGroupInviteHandlers.snapshot()
+
+		for _, handler := range handlers {
+			gc.client.runHandler(ComponentGroupChats, fromDID, func() { handler(invitation) })
+		}
+
+		gc.client.publish(Event{Type: EventGroupInvite, PeerDID: fromDID, GroupInvite: invitation})
+	}
+}
+
+// removeMember drops a peer from every group it is a member of
+func (gc *GroupChats) removeMember(peerDID string) {
+	gc.mu.RLock()
+	var left []string
+	for _, group := range gc.groups {
+		group.mu.Lock()
+		if _, exists := group.members[peerDID]; exists {
+			delete(group.members, peerDID)
+			left = append(left, group.id)
+		}
+		group.mu.Unlock()
+	}
+	gc.mu.RUnlock()
+
+	// Notify handlers
+	handlers := gc.onMemberLeftHandlers.snapshot()
 
+	for _, groupID := range left {
 		for _, handler := range handlers {
-			go handler(invitation)
+			gc.client.runHandler(ComponentGroupChats, peerDID, func() { handler(groupID, peerDID) })
 		}
 	}
 }
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"encoding/hex"
+	"sync"
+
+	"github.com/joinself/self-go-sdk/message"
+)
+
+// contentHandlers holds handlers registered for custom content types
+type contentHandlers struct {
+	byType    map[message.ContentType]*handlerList[func(InboundMessage)]
+	unhandled handlerList[func(InboundMessage)]
+	onError   handlerList[func(ErrorEvent)]
+}
+
+// RegisterContentHandler registers a handler for messages of the given content type.
+// Handlers run for any content type, including those the client routes to its
+// components, so custom protocols can be added without modifying the client.
+func (c *Client) RegisterContentHandler(contentType message.ContentType, handler func(InboundMessage)) Unsubscribe {
+	c.handlerMu.Lock()
+	defer c.handlerMu.Unlock()
+	if c.handlers.byType == nil {
+		c.handlers.byType = make(map[message.ContentType]*handlerList[func(InboundMessage)])
+	}
+	handlers, ok := c.handlers.byType[contentType]
+	if !ok {
+		handlers = &handlerList[func(InboundMessage)]{}
+		c.handlers.byType[contentType] = handlers
+	}
+	return handlers.add(handler)
+}
+
+// OnUnhandledMessage registers a catch-all handler for messages that are
+// neither routed to a component nor matched by a registered content handler
+func (c *Client) OnUnhandledMessage(handler func(InboundMessage)) Unsubscribe {
+	return c.handlers.unhandled.add(handler)
+}
+
+// OnError registers a handler for errors that occur while handling events in
+// the background, such as failed connection handshakes or undecodable messages
+func (c *Client) OnError(handler func(ErrorEvent)) Unsubscribe {
+	return c.handlers.onError.add(handler)
+}
+
+// reportError notifies error handlers of a background error
+func (c *Client) reportError(event ErrorEvent) {
+	handlers := c.handlers.onError.snapshot()
+
+	c.logger.Warn("background operation failed",
+		logKeyComponent, event.Component,
+		logKeyOperation, event.Operation,
+		logKeyPeer, event.PeerDID,
+		logKeyMessageID, event.MessageID,
+		"error", event.Err,
+	)
+
+	for _, handler := range handlers {
+		c.dispatcher.submitTask(dispatchTask{
+			component:    event.Component,
+			peerDID:      event.PeerDID,
+			handler:      func() { handler(event) },
+			errorHandler: true,
+		})
+	}
+
+	c.publish(Event{Type: EventError, PeerDID: event.PeerDID, Error: &event})
+}
+
+// reportMessageError reports an error that occurred while handling a message
+func (c *Client) reportMessageError(component, operation string, msg InboundMessage, err error) {
+	c.reportError(ErrorEvent{
+		Component: component,
+		Operation: operation,
+		PeerDID:   msg.FromAddress().String(),
+		MessageID: hex.EncodeToString(msg.ID()),
+		Err:       err,
+	})
+}
+
+// dispatchContentHandlers notifies registered content handlers, falling back
+// to the unhandled message handlers when nothing else processed the message
+func (c *Client) dispatchContentHandlers(contentType message.ContentType, msg InboundMessage, routed bool) {
+	var handlers []func(InboundMessage)
+
+	c.handlerMu.RLock()
+	if typed, ok := c.handlers.byType[contentType]; ok {
+		handlers = typed.snapshot()
+	}
+	c.handlerMu.RUnlock()
+
+	if !routed && len(handlers) == 0 {
+		handlers = c.handlers.unhandled.snapshot()
+	}
+
+	peerDID := msg.FromAddress().String()
+	for _, handler := range handlers {
+		c.runHandler(ComponentClient, peerDID, func() { handler(msg) })
+	}
+}
+
+// Unsubscribe removes a registered handler. Calling it more than once has no effect.
+type Unsubscribe func()
+
+// handlerList is a set of registered handlers that can be removed at runtime
+type handlerList[T any] struct {
+	entries []handlerEntry[T]
+	nextID  uint64
+	mu      sync.RWMutex
+}
+
+// handlerEntry is a handler with the ID used to remove it
+type handlerEntry[T any] struct {
+	id      uint64
+	handler T
+}
+
+// add registers a handler and returns a function that removes it
+func (l *handlerList[T]) add(handler T) Unsubscribe {
+	l.mu.Lock()
+	l.nextID++
+	id := l.nextID
+	l.entries = append(l.entries, handlerEntry[T]{id: id, handler: handler})
+	l.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { l.remove(id) })
+	}
+}
+
+// remove removes the handler with the given ID
+func (l *handlerList[T]) remove(id uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, entry := range l.entries {
+		if entry.id == id {
+			// Copy so snapshots taken earlier are not modified
+			entries := make([]handlerEntry[T], 0, len(l.entries)-1)
+			entries = append(entries, l.entries[:i]...)
+			l.entries = append(entries, l.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// snapshot returns the currently registered handlers
+func (l *handlerList[T]) snapshot() []T {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	handlers := make([]T, len(l.entries))
+	for i, entry := range l.entries {
+		handlers[i] = entry.handler
+	}
+	return handlers
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterContentHandler(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	received := make(chan InboundMessage, 1)
+	client.RegisterContentHandler(message.ContentTypeCredential, func(msg InboundMessage) {
+		received <- msg
+	})
+
+	unhandled := make(chan InboundMessage, 1)
+	client.OnUnhandledMessage(func(msg InboundMessage) {
+		unhandled <- msg
+	})
+
+	content, err := message.NewCredential().Finish()
+	require.NoError(t, err)
+
+	from := testAddress(t)
+	transport.receive(from, content)
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, from.String(), msg.FromAddress().String())
+		assert.Equal(t, content.ID(), msg.ID())
+	case <-time.After(time.Second):
+		t.Fatal("content handler was not called")
+	}
+
+	select {
+	case <-unhandled:
+		t.Fatal("unhandled handler called for a registered content type")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestOnUnhandledMessage(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	unhandled := make(chan InboundMessage, 1)
+	client.OnUnhandledMessage(func(msg InboundMessage) {
+		unhandled <- msg
+	})
+
+	content, err := message.NewCredential().Finish()
+	require.NoError(t, err)
+
+	transport.receive(testAddress(t), content)
+
+	select {
+	case msg := <-unhandled:
+		assert.Equal(t, message.ContentTypeCredential, msg.Content().ContentType())
+	case <-time.After(time.Second):
+		t.Fatal("unhandled message handler was not called")
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	removed := make(chan ChatMessage, 1)
+	unsubscribe := client.Chat().OnMessage(func(msg ChatMessage) {
+		removed <- msg
+	})
+
+	kept := make(chan ChatMessage, 1)
+	client.Chat().OnMessage(func(msg ChatMessage) {
+		kept <- msg
+	})
+
+	unsubscribe()
+	unsubscribe() // Calling again has no effect
+
+	content, err := message.NewChat().Message("hello").Finish()
+	require.NoError(t, err)
+	transport.receive(testAddress(t), content)
+
+	select {
+	case msg := <-kept:
+		assert.Equal(t, "hello", msg.Text())
+	case <-time.After(time.Second):
+		t.Fatal("remaining handler was not called")
+	}
+
+	select {
+	case <-removed:
+		t.Fatal("removed handler was called")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestHandlerListRemoveKeepsSnapshots(t *testing.T) {
+	var list handlerList[func() int]
+	list.add(func() int { return 1 })
+	remove := list.add(func() int { return 2 })
+	list.add(func() int { return 3 })
+
+	before := list.snapshot()
+	remove()
+	after := list.snapshot()
+
+	require.Len(t, before, 3)
+	assert.Equal(t, 2, before[1]())
+	require.Len(t, after, 2)
+	assert.Equal(t, 1, after[0]())
+	assert.Equal(t, 3, after[1]())
+}
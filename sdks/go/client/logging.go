@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Structured log field names
+const (
+	logKeyComponent   = "component"
+	logKeyPeer        = "peer"
+	logKeyRequestID   = "requestID"
+	logKeyContentType = "contentType"
+	logKeyMessageID   = "messageID"
+	logKeyOperation   = "operation"
+)
+
+// discardHandler is a slog handler that drops all records
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// newLogger returns the configured logger, or one that discards everything
+func newLogger(config *Config) *slog.Logger {
+	if config.Logger != nil {
+		return config.Logger
+	}
+	return slog.New(discardHandler{})
+}
+
+// Logger returns the logger the client writes to
+func (c *Client) Logger() *slog.Logger {
+	return c.logger
+}
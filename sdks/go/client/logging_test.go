@@ -0,0 +1,71 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes
+type syncBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLoggerRecordsRoutingAndPanics(t *testing.T) {
+	output := &syncBuffer{}
+	transport := newFakeTransport(t)
+
+	client, err := New(Config{
+		Transport: transport.factory(),
+		Logger:    slog.New(slog.NewTextHandler(output, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	client.Chat().OnMessage(func(msg ChatMessage) {
+		panic("handler failure")
+	})
+
+	content, err := message.NewChat().Message("hello").Finish()
+	require.NoError(t, err)
+
+	peer := testAddress(t)
+	transport.receive(peer, content)
+
+	assert.Eventually(t, func() bool {
+		return strings.Contains(output.String(), "handler panicked")
+	}, time.Second, 10*time.Millisecond)
+
+	logs := output.String()
+	assert.Contains(t, logs, `msg="message received"`)
+	assert.Contains(t, logs, "peer="+peer.String())
+	assert.Contains(t, logs, "component=chat")
+	assert.Contains(t, logs, "panic=\"handler failure\"")
+}
+
+func TestDefaultLoggerDiscards(t *testing.T) {
+	client, _ := newTestClient(t)
+	assert.NotNil(t, client.Logger())
+	assert.False(t, client.Logger().Enabled(context.Background(), slog.LevelError))
+}
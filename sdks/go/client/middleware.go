@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+
+	"github.com/joinself/self-go-sdk/message"
+)
+
+// MessageDirection indicates whether a message is being received or sent
+type MessageDirection int
+
+const (
+	Inbound MessageDirection = iota
+	Outbound
+)
+
+// String returns the direction name
+func (d MessageDirection) String() string {
+	if d == Outbound {
+		return "outbound"
+	}
+	return "inbound"
+}
+
+// MessageContext describes a message passing through the middleware chain
+type MessageContext struct {
+	// Context is the caller's context for outbound messages, carrying
+	// deadlines and trace values, and context.Background() for inbound ones
+	Context context.Context
+
+	// Direction is Inbound for received messages and Outbound for sent ones
+	Direction MessageDirection
+
+	// ContentType is the type of the content. It is updated to match
+	// replaced content before the message is routed or sent.
+	ContentType message.ContentType
+
+	// PeerDID is the sender of inbound messages or the recipient of outbound ones
+	PeerDID string
+
+	// Content is the message payload. Middleware may replace it to mutate
+	// the message before it is routed or sent. Setting it to nil rejects
+	// the message with ErrMessageRejected.
+	Content *message.Content
+
+	// Message is the received message (inbound only)
+	Message InboundMessage
+
+	// Notification is true when outbound content is delivered as a push notification
+	Notification bool
+}
+
+// MessageHandler processes a message that passed through the middleware chain
+type MessageHandler func(mc *MessageContext) error
+
+// Middleware wraps message processing. A middleware can inspect or mutate the
+// context before calling next, reject the message by returning an error, or
+// short-circuit by returning nil without calling next.
+type Middleware func(next MessageHandler) MessageHandler
+
+// Use adds middleware to the inbound and outbound message pipeline.
+// Middleware runs in the order it was added.
+func (c *Client) Use(middleware ...Middleware) {
+	c.middlewareMu.Lock()
+	defer c.middlewareMu.Unlock()
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// runMiddleware passes a message through the middleware chain to the final handler
+func (c *Client) runMiddleware(mc *MessageContext, final MessageHandler) error {
+	c.middlewareMu.RLock()
+	chain := make([]Middleware, len(c.middleware))
+	copy(chain, c.middleware)
+	c.middlewareMu.RUnlock()
+
+	handler := func(mc *MessageContext) error {
+		if mc.Content == nil {
+			return ErrMessageRejected
+		}
+		// Middleware may have replaced the content with another type
+		mc.ContentType = mc.Content.ContentType()
+		return final(mc)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		handler = chain[i](handler)
+	}
+
+	return handler(mc)
+}
+
+// rewrittenMessage is an inbound message whose content was replaced by middleware
+type rewrittenMessage struct {
+	InboundMessage
+	content *message.Content
+}
+
+// Content returns the replacement content
+func (m *rewrittenMessage) Content() *message.Content {
+	return m.content
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareRejectsOutbound(t *testing.T) {
+	client, transport := newTestClient(t)
+	peer := testAddress(t)
+
+	var seen *MessageContext
+	client.Use(func(next MessageHandler) MessageHandler {
+		return func(mc *MessageContext) error {
+			seen = mc
+			return ErrMessageRejected
+		}
+	})
+
+	err := client.Chat().Send(peer.String(), "blocked")
+	assert.ErrorIs(t, err, ErrMessageRejected)
+	assert.Equal(t, 0, transport.sentCount())
+
+	require.NotNil(t, seen)
+	assert.Equal(t, Outbound, seen.Direction)
+	assert.Equal(t, message.ContentTypeChat, seen.ContentType)
+	assert.Equal(t, peer.String(), seen.PeerDID)
+}
+
+func TestMiddlewareMutatesOutbound(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	replacement, err := message.NewChat().Message("rewritten").Finish()
+	require.NoError(t, err)
+
+	client.Use(func(next MessageHandler) MessageHandler {
+		return func(mc *MessageContext) error {
+			mc.Content = replacement
+			return next(mc)
+		}
+	})
+
+	require.NoError(t, client.Chat().Send(testAddress(t).String(), "original"))
+	require.Equal(t, 1, transport.sentCount())
+	assert.Same(t, replacement, transport.sent[0])
+}
+
+func TestMiddlewareOrderAndInboundShortCircuit(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	var order []string
+	client.Use(
+		func(next MessageHandler) MessageHandler {
+			return func(mc *MessageContext) error {
+				order = append(order, "first")
+				return next(mc)
+			}
+		},
+		func(next MessageHandler) MessageHandler {
+			return func(mc *MessageContext) error {
+				order = append(order, "second")
+				if mc.Direction == Inbound {
+					return nil // Drop inbound messages
+				}
+				return next(mc)
+			}
+		},
+	)
+
+	received := make(chan ChatMessage, 1)
+	client.Chat().OnMessage(func(msg ChatMessage) {
+		received <- msg
+	})
+
+	content, err := message.NewChat().Message("dropped").Finish()
+	require.NoError(t, err)
+	transport.receive(testAddress(t), content)
+
+	assert.Equal(t, []string{"first", "second"}, order)
+
+	select {
+	case <-received:
+		t.Fatal("short-circuited message reached chat handlers")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMiddlewareNilContentRejects(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	client.Use(func(next MessageHandler) MessageHandler {
+		return func(mc *MessageContext) error {
+			mc.Content = nil
+			return next(mc)
+		}
+	})
+
+	events := make(chan ErrorEvent, 1)
+	client.OnError(func(event ErrorEvent) {
+		events <- event
+	})
+
+	err := client.Chat().Send(testAddress(t).String(), "dropped")
+	assert.ErrorIs(t, err, ErrMessageRejected)
+	assert.Equal(t, 0, transport.sentCount())
+
+	content, err := message.NewChat().Message("dropped").Finish()
+	require.NoError(t, err)
+	transport.receive(testAddress(t), content)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "Middleware", event.Operation)
+		assert.ErrorIs(t, event, ErrMessageRejected)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error event")
+	}
+}
+
+func TestMiddlewareRewriteUpdatesContentType(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	replacement, err := message.NewDiscoveryResponse().
+		ResponseTo([]byte("request")).
+		Status(message.ResponseStatusAccepted).
+		Finish()
+	require.NoError(t, err)
+
+	var routed message.ContentType
+	client.Use(
+		func(next MessageHandler) MessageHandler {
+			return func(mc *MessageContext) error {
+				err := next(mc)
+				routed = mc.ContentType
+				return err
+			}
+		},
+		func(next MessageHandler) MessageHandler {
+			return func(mc *MessageContext) error {
+				mc.Content = replacement
+				return next(mc)
+			}
+		},
+	)
+
+	require.NoError(t, client.Chat().Send(testAddress(t).String(), "original"))
+	require.Equal(t, 1, transport.sentCount())
+	assert.Equal(t, message.ContentTypeDiscoveryResponse, routed)
+}
@@ -1,7 +1,7 @@
 package client
 
 import (
-	"sync"
+	"context"
 
 	"github.com/joinself/self-go-sdk/keypair/signing"
 	"github.com/joinself/self-go-sdk/message"
@@ -14,6 +14,9 @@ type NotificationSummary struct {
 	MessageType string
 	FromDID     string
 	MessageID   string
+
+	// PeerName is the recipient's display name from the contact book, set when sent
+	PeerName string
 }
 
 // Notifications handles push notification functionality
@@ -21,8 +24,7 @@ type Notifications struct {
 	client *Client
 
 	// Event handlers
-	onNotificationSentHandlers []func(peerDID string, summary *NotificationSummary)
-	mu                         sync.RWMutex
+	onNotificationSentHandlers handlerList[func(peerDID string, summary *NotificationSummary)]
 }
 
 // newNotifications creates a new notifications component
@@ -34,6 +36,11 @@ func newNotifications(client *Client) *Notifications {
 
 // SendNotification sends a push notification to a peer
 func (n *Notifications) SendNotification(peerDID string, summary *NotificationSummary) error {
+	return n.SendNotificationContext(context.Background(), peerDID, summary)
+}
+
+// SendNotificationContext sends a push notification to a peer, giving up when ctx is done
+func (n *Notifications) SendNotificationContext(ctx context.Context, peerDID string, summary *NotificationSummary) error {
 	if n.client.isClosed() {
 		return ErrClientClosed
 	}
@@ -44,6 +51,13 @@ func (n *Notifications) SendNotification(peerDID string, summary *NotificationSu
 		return ErrInvalidPeerDID
 	}
 
+	// Name the recipient for handlers without changing the caller's summary
+	if summary.PeerName == "" {
+		named := *summary
+		named.PeerName = n.client.contacts.DisplayName(peerDID)
+		summary = &named
+	}
+
 	// Create a simple chat message to generate a content summary
 	// This is a workaround since ContentSummary doesn't have a direct constructor
 	chatBuilder := message.NewChat().Message(summary.Body)
@@ -52,29 +66,59 @@ func (n *Notifications) SendNotification(peerDID string, summary *NotificationSu
 		return err
 	}
 
-	// Generate the content summary from the message
-	contentSummary, err := content.Summary()
-	if err != nil {
+	queued := false
+	err = n.send(ctx, peerAddress, *content, func(mc *MessageContext, err error) error {
+		if mc.Context.Err() == nil && n.client.outbox.enabled(OutboxNotification) {
+			queued = true
+			return n.client.outbox.enqueue(OutboxNotification, peerDID, content, summary, err)
+		}
 		return err
-	}
-
-	// Send the notification
-	err = n.client.account.NotificationSend(peerAddress, contentSummary)
-	if err != nil {
+	})
+	if err != nil || queued {
+		// Queued notifications are reported by the outbox once delivered
 		return err
 	}
 
-	// Notify handlers
-	n.mu.RLock()
-	handlers := make([]func(string, *NotificationSummary), len(n.onNotificationSentHandlers))
-	copy(handlers, n.onNotificationSentHandlers)
-	n.mu.RUnlock()
+	n.sent(peerDID, summary)
+	return nil
+}
+
+// sent notifies handlers of a notification that was sent
+func (n *Notifications) sent(peerDID string, summary *NotificationSummary) {
+	handlers := n.onNotificationSentHandlers.snapshot()
 
 	for _, handler := range handlers {
-		go handler(peerDID, summary)
+		n.client.runHandler(ComponentNotifications, peerDID, func() { handler(peerDID, summary) })
 	}
+}
 
-	return nil
+// send sends content as a push notification through the middleware chain.
+// When the transport fails to send it and fallback is set, fallback decides
+// the result.
+func (n *Notifications) send(ctx context.Context, to *signing.PublicKey, content message.Content, fallback func(mc *MessageContext, err error) error) error {
+	mc := &MessageContext{
+		Context:      ctx,
+		Direction:    Outbound,
+		ContentType:  content.ContentType(),
+		PeerDID:      to.String(),
+		Content:      &content,
+		Notification: true,
+	}
+
+	return n.client.runMiddleware(mc, func(mc *MessageContext) error {
+		// Generate the content summary from the (possibly rewritten) message
+		contentSummary, err := mc.Content.Summary()
+		if err != nil {
+			return err
+		}
+		err = runContext(mc.Context, func() error {
+			return n.client.transport.NotificationSend(to, contentSummary)
+		})
+		if err != nil && fallback != nil {
+			return fallback(mc, err)
+		}
+		return err
+	})
 }
 
 // SendChatNotification sends a notification for a chat message
@@ -215,11 +259,10 @@ func (n *Notifications) CreateSummaryFromContent(content *message.Content) (*Not
 	return summary, nil
 }
 
-// OnNotificationSent registers a handler for when notifications are sent
-func (n *Notifications) OnNotificationSent(handler func(peerDID string, summary *NotificationSummary)) {
-	n.mu.Lock()
-	defer n.mu.Unlock()
-	n.onNotificationSentHandlers = append(n.onNotificationSentHandlers, handler)
+// OnNotificationSent registers a handler for when notifications are sent,
+// including queued notifications once the outbox delivers them
+func (n *Notifications) OnNotificationSent(handler func(peerDID string, summary *NotificationSummary)) Unsubscribe {
+	return n.onNotificationSentHandlers.add(handler)
 }
 
 // Internal methods for handling events
@@ -236,7 +279,7 @@ func (n *Notifications) onWelcome(from *signing.PublicKey, groupAddress *signing
 	// New connection established - no specific action needed
 }
 
-func (n *Notifications) onKeyPackage(from *signing.PublicKey) {
+func (n *Notifications) onKeyPackage(from *signing.PublicKey, groupAddress *signing.PublicKey) {
 	// Key package received - no specific action needed
 }
 
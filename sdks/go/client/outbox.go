@@ -0,0 +1,520 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joinself/self-go-sdk/event"
+	"github.com/joinself/self-go-sdk/keypair/signing"
+	"github.com/joinself/self-go-sdk/message"
+)
+
+// outboxStorageKey is where the outbox is persisted in encrypted storage
+const outboxStorageKey = "self-client:outbox"
+
+const (
+	defaultOutboxMaxAttempts  = 10
+	defaultOutboxInitialDelay = 5 * time.Second
+	defaultOutboxMaxDelay     = 5 * time.Minute
+	defaultOutboxMaxEntries   = 1000
+)
+
+// OutboxKind identifies the type of content held in the outbox
+type OutboxKind int
+
+const (
+	OutboxChat OutboxKind = iota
+	OutboxCredentialResponse
+	OutboxNotification
+)
+
+// String returns the outbox kind name
+func (k OutboxKind) String() string {
+	switch k {
+	case OutboxChat:
+		return "chat"
+	case OutboxCredentialResponse:
+		return "credential_response"
+	case OutboxNotification:
+		return "notification"
+	default:
+		return "unknown"
+	}
+}
+
+// OutboxConfig controls the durable outbox. Content of an enabled kind that
+// fails to send is persisted and retried instead of returning the error.
+type OutboxConfig struct {
+	// Chat queues chat messages and replies
+	Chat bool
+
+	// CredentialResponses queues responses to incoming credential requests
+	CredentialResponses bool
+
+	// Notifications queues push notifications
+	Notifications bool
+
+	// MaxAttempts is the number of send attempts before giving up (default: 10)
+	MaxAttempts int
+
+	// InitialDelay is the delay before the first retry (default: 5s)
+	InitialDelay time.Duration
+
+	// MaxDelay caps the delay between retries (default: 5m)
+	MaxDelay time.Duration
+
+	// MaxEntries limits the number of queued entries (default: 1000)
+	MaxEntries int
+}
+
+// OutboxEntry describes content waiting in the outbox
+type OutboxEntry struct {
+	// ID is the hex encoded ID of the queued content
+	ID string
+
+	// Kind is the type of content
+	Kind OutboxKind
+
+	// PeerDID is the recipient
+	PeerDID string
+
+	// Attempts is the number of send attempts made so far
+	Attempts int
+
+	// CreatedAt is when the content was queued
+	CreatedAt time.Time
+
+	// NextAttempt is when the next attempt is due
+	NextAttempt time.Time
+
+	// LastError is the error of the last attempt
+	LastError string
+}
+
+// outboxRecord is a persisted outbox entry. Content is the queued content
+// encoded by the SDK, so it is restored with the same ID and attachments.
+type outboxRecord struct {
+	OutboxEntry
+	Content []byte
+
+	// Notification is the summary of a queued notification, reported to
+	// OnNotificationSent handlers once it is delivered
+	Notification *NotificationSummary `json:",omitempty"`
+
+	content *message.Content
+}
+
+// Outbox holds outbound content that failed to send and retries it
+type Outbox struct {
+	client  *Client
+	config  OutboxConfig
+	backoff backoff
+
+	records []*outboxRecord
+	wake    chan struct{}
+	mu      sync.Mutex
+
+	// Event handlers
+	onDeliveredHandlers handlerList[func(OutboxEntry)]
+	onGaveUpHandlers    handlerList[func(OutboxEntry, error)]
+}
+
+// newOutbox creates the outbox component, restoring persisted entries when
+// any kind of content is enabled
+func newOutbox(client *Client) *Outbox {
+	config := client.config.Outbox
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultOutboxMaxAttempts
+	}
+	if config.InitialDelay <= 0 {
+		config.InitialDelay = defaultOutboxInitialDelay
+	}
+	if config.MaxDelay < config.InitialDelay {
+		config.MaxDelay = defaultOutboxMaxDelay
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = defaultOutboxMaxEntries
+	}
+
+	o := &Outbox{
+		client: client,
+		config: config,
+		backoff: backoff{
+			initial:    config.InitialDelay,
+			max:        config.MaxDelay,
+			multiplier: 2,
+		},
+		wake: make(chan struct{}, 1),
+	}
+
+	if config.Chat || config.CredentialResponses || config.Notifications {
+		o.load()
+		go o.run()
+	}
+	return o
+}
+
+// OnDelivered registers a handler for queued content that was delivered
+func (o *Outbox) OnDelivered(handler func(OutboxEntry)) Unsubscribe {
+	return o.onDeliveredHandlers.add(handler)
+}
+
+// OnGaveUp registers a handler for queued content that was given up on
+func (o *Outbox) OnGaveUp(handler func(OutboxEntry, error)) Unsubscribe {
+	return o.onGaveUpHandlers.add(handler)
+}
+
+// Pending returns the entries waiting to be sent, oldest first
+func (o *Outbox) Pending() []OutboxEntry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entries := make([]OutboxEntry, len(o.records))
+	for i, record := range o.records {
+		entries[i] = record.OutboxEntry
+	}
+	return entries
+}
+
+// enabled reports whether content of the given kind is queued on failure
+func (o *Outbox) enabled(kind OutboxKind) bool {
+	switch kind {
+	case OutboxChat:
+		return o.config.Chat
+	case OutboxCredentialResponse:
+		return o.config.CredentialResponses
+	case OutboxNotification:
+		return o.config.Notifications
+	default:
+		return false
+	}
+}
+
+// send sends a message, queueing it if the transport fails and its kind is enabled
+func (o *Outbox) send(ctx context.Context, kind OutboxKind, to *signing.PublicKey, content message.Content) error {
+	if !o.enabled(kind) {
+		return o.client.sendMessageContext(ctx, to, content)
+	}
+
+	return o.client.sendMessageOr(ctx, to, content, func(mc *MessageContext, err error) error {
+		// A cancelled send may still complete, so it is not retried
+		if mc.Context.Err() != nil {
+			return err
+		}
+		// Retries run the middleware again, so the original content is queued
+		return o.enqueue(kind, mc.PeerDID, &content, nil, err)
+	})
+}
+
+// enqueue adds content that failed to send to the outbox. Notifications
+// carry their summary for the sent handlers.
+func (o *Outbox) enqueue(kind OutboxKind, peerDID string, content *message.Content, summary *NotificationSummary, cause error) error {
+	data, err := event.NewAnonymousMessage(content).Encode()
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox content: %w", err)
+	}
+
+	now := time.Now()
+	record := &outboxRecord{
+		OutboxEntry: OutboxEntry{
+			ID:          hex.EncodeToString(content.ID()),
+			Kind:        kind,
+			PeerDID:     peerDID,
+			Attempts:    1,
+			CreatedAt:   now,
+			NextAttempt: now.Add(o.backoff.delay(1)),
+			LastError:   cause.Error(),
+		},
+		Content:      data,
+		Notification: summary,
+		content:      content,
+	}
+
+	o.mu.Lock()
+	if len(o.records) >= o.config.MaxEntries {
+		o.mu.Unlock()
+		return fmt.Errorf("%w: %w", ErrOutboxFull, cause)
+	}
+	o.records = append(o.records, record)
+	err = o.persistLocked()
+	o.mu.Unlock()
+	o.reportPersist(err)
+
+	o.client.logger.Info("message queued in outbox",
+		logKeyComponent, ComponentOutbox,
+		logKeyPeer, peerDID,
+		logKeyMessageID, record.ID,
+		"kind", kind.String(),
+		"error", cause,
+	)
+
+	o.signal()
+	return nil
+}
+
+// signal wakes the retry loop
+func (o *Outbox) signal() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run retries queued content as it falls due until the client closes
+func (o *Outbox) run() {
+	for {
+		var timer *time.Timer
+		var due <-chan time.Time
+		if next := o.nextAttempt(); !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			due = timer.C
+		}
+
+		select {
+		case <-o.client.done:
+		case <-o.wake:
+		case <-due:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+
+		if o.client.isClosed() {
+			return
+		}
+		o.flush(time.Now())
+	}
+}
+
+// nextAttempt returns when the earliest entry is due, or zero if there are none
+func (o *Outbox) nextAttempt() time.Time {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var next time.Time
+	for _, record := range o.records {
+		if next.IsZero() || record.NextAttempt.Before(next) {
+			next = record.NextAttempt
+		}
+	}
+	return next
+}
+
+// flush attempts every entry that is due while the client is connected
+func (o *Outbox) flush(now time.Time) {
+	switch o.client.State() {
+	case StateDisconnected, StateClosed:
+		// Retried once the connection comes back
+		return
+	}
+
+	o.mu.Lock()
+	var due []*outboxRecord
+	for _, record := range o.records {
+		if !record.NextAttempt.After(now) {
+			due = append(due, record)
+		}
+	}
+	o.mu.Unlock()
+
+	for _, record := range due {
+		err := o.attempt(record)
+
+		// Content rejected by middleware would be rejected again
+		rejected := errors.Is(err, ErrMessageRejected)
+
+		o.mu.Lock()
+		record.Attempts++
+		done := err == nil || rejected || record.Attempts >= o.config.MaxAttempts
+		if err != nil {
+			record.LastError = err.Error()
+			record.NextAttempt = now.Add(o.backoff.delay(record.Attempts))
+		}
+		if done {
+			o.removeLocked(record)
+		}
+		persistErr := o.persistLocked()
+		entry := record.OutboxEntry
+		o.mu.Unlock()
+		o.reportPersist(persistErr)
+
+		switch {
+		case err == nil:
+			o.delivered(entry)
+			if record.Notification != nil {
+				o.client.notifications.sent(entry.PeerDID, record.Notification)
+			}
+		case rejected:
+			o.gaveUp(entry, err)
+		case done:
+			o.gaveUp(entry, fmt.Errorf("%w after %d attempts: %w", ErrOutboxGaveUp, entry.Attempts, err))
+		}
+	}
+}
+
+// attempt sends a queued entry through the middleware chain
+func (o *Outbox) attempt(record *outboxRecord) error {
+	to := signing.FromAddress(record.PeerDID)
+	if to == nil {
+		return ErrInvalidPeerDID
+	}
+
+	if record.Kind == OutboxNotification {
+		return o.client.notifications.send(context.Background(), to, *record.content, nil)
+	}
+	return o.client.sendMessageOr(context.Background(), to, *record.content, nil)
+}
+
+// delivered reports an entry that was sent
+func (o *Outbox) delivered(entry OutboxEntry) {
+	o.client.logger.Info("outbox message delivered",
+		logKeyComponent, ComponentOutbox,
+		logKeyPeer, entry.PeerDID,
+		logKeyMessageID, entry.ID,
+		"attempts", entry.Attempts,
+	)
+
+	handlers := o.onDeliveredHandlers.snapshot()
+
+	for _, handler := range handlers {
+		o.client.runHandler(ComponentOutbox, entry.PeerDID, func() { handler(entry) })
+	}
+
+	o.client.publish(Event{Type: EventOutboxDelivered, PeerDID: entry.PeerDID, OutboxEntry: &entry})
+}
+
+// gaveUp reports an entry that will not be retried
+func (o *Outbox) gaveUp(entry OutboxEntry, err error) {
+	o.client.logger.Warn("outbox message given up",
+		logKeyComponent, ComponentOutbox,
+		logKeyPeer, entry.PeerDID,
+		logKeyMessageID, entry.ID,
+		"error", err,
+	)
+
+	handlers := o.onGaveUpHandlers.snapshot()
+
+	for _, handler := range handlers {
+		o.client.runHandler(ComponentOutbox, entry.PeerDID, func() { handler(entry, err) })
+	}
+
+	o.client.publish(Event{Type: EventOutboxGaveUp, PeerDID: entry.PeerDID, OutboxEntry: &entry, Cause: err})
+}
+
+// dropPeer removes the entries queued for a peer, reporting them as given up
+func (o *Outbox) dropPeer(peerDID string, err error) {
+	o.mu.Lock()
+	var dropped []OutboxEntry
+	var persistErr error
+	kept := make([]*outboxRecord, 0, len(o.records))
+	for _, record := range o.records {
+		if record.PeerDID == peerDID {
+			dropped = append(dropped, record.OutboxEntry)
+		} else {
+			kept = append(kept, record)
+		}
+	}
+	if len(dropped) > 0 {
+		o.records = kept
+		persistErr = o.persistLocked()
+	}
+	o.mu.Unlock()
+	o.reportPersist(persistErr)
+
+	for _, entry := range dropped {
+		o.gaveUp(entry, err)
+	}
+}
+
+// removeLocked drops a record from the outbox with mu held
+func (o *Outbox) removeLocked(record *outboxRecord) {
+	for i, r := range o.records {
+		if r == record {
+			o.records = append(o.records[:i:i], o.records[i+1:]...)
+			return
+		}
+	}
+}
+
+// persistLocked writes the outbox to encrypted storage with mu held. The
+// caller reports the error with reportPersist once mu is released.
+func (o *Outbox) persistLocked() error {
+	return o.client.storage.StoreJSON(outboxStorageKey, o.records)
+}
+
+// reportPersist reports a failure to persist the outbox
+func (o *Outbox) reportPersist(err error) {
+	if err != nil {
+		o.client.reportError(ErrorEvent{
+			Component: ComponentOutbox,
+			Operation: "Persist",
+			Err:       err,
+		})
+	}
+}
+
+// load restores persisted entries, dropping those that cannot be rebuilt
+func (o *Outbox) load() {
+	var records []*outboxRecord
+	if err := o.client.storage.LookupJSON(outboxStorageKey, &records); err != nil {
+		// Nothing has been queued yet
+		return
+	}
+
+	for _, record := range records {
+		content, err := record.rebuild()
+		if err != nil {
+			o.client.logger.Warn("dropping outbox entry",
+				logKeyComponent, ComponentOutbox,
+				logKeyPeer, record.PeerDID,
+				logKeyMessageID, record.ID,
+				"error", err,
+			)
+			continue
+		}
+		record.content = content
+		o.records = append(o.records, record)
+	}
+
+	if len(o.records) != len(records) {
+		o.reportPersist(o.persistLocked())
+	}
+}
+
+// rebuild decodes the content of a persisted record
+func (r *outboxRecord) rebuild() (*message.Content, error) {
+	if len(r.Content) == 0 {
+		return nil, errors.New("content was not persisted")
+	}
+
+	anonymousMsg, err := event.DecodeAnonymousMessage(r.Content)
+	if err != nil {
+		return nil, err
+	}
+	if anonymousMsg.Content() == nil {
+		return nil, errors.New("content could not be decoded")
+	}
+	return anonymousMsg.Content(), nil
+}
+
+// Internal methods for handling events
+
+func (o *Outbox) onConnect() {
+	// Everything queued is due as soon as the connection is back
+	o.mu.Lock()
+	now := time.Now()
+	for _, record := range o.records {
+		record.NextAttempt = now
+	}
+	o.mu.Unlock()
+
+	o.signal()
+}
+
+func (o *Outbox) close() {
+	// Entries stay persisted for the next run
+}
@@ -0,0 +1,210 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errSendFailed = errors.New("send failed")
+
+// newOutboxClient creates a client on the given transport with the outbox enabled
+func newOutboxClient(t *testing.T, transport *fakeTransport, outbox OutboxConfig) *Client {
+	client, err := New(Config{Transport: transport.factory(), Outbox: outbox})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestOutboxDelivers(t *testing.T) {
+	transport := newFakeTransport(t)
+	client := newOutboxClient(t, transport, OutboxConfig{Chat: true, InitialDelay: 5 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := client.Events(ctx, EventFilter{Types: []EventType{EventOutboxDelivered}})
+
+	peer := testAddress(t)
+	transport.failSends(errSendFailed)
+	require.NoError(t, client.Chat().Send(peer.String(), "hello"))
+
+	pending := client.Outbox().Pending()
+	require.Len(t, pending, 1)
+	assert.Equal(t, OutboxChat, pending[0].Kind)
+	assert.Equal(t, peer.String(), pending[0].PeerDID)
+	assert.Equal(t, errSendFailed.Error(), pending[0].LastError)
+
+	transport.failSends(nil)
+
+	event := nextEvent(t, events)
+	require.NotNil(t, event.OutboxEntry)
+	assert.Equal(t, pending[0].ID, event.OutboxEntry.ID)
+	assert.Empty(t, client.Outbox().Pending())
+	assert.Equal(t, 1, transport.sentCount())
+}
+
+func TestOutboxGivesUp(t *testing.T) {
+	transport := newFakeTransport(t)
+	client := newOutboxClient(t, transport, OutboxConfig{
+		Chat:         true,
+		InitialDelay: time.Millisecond,
+		MaxAttempts:  3,
+	})
+
+	gaveUp := make(chan error, 1)
+	client.Outbox().OnGaveUp(func(entry OutboxEntry, err error) {
+		assert.Equal(t, 3, entry.Attempts)
+		gaveUp <- err
+	})
+
+	transport.failSends(errSendFailed)
+	require.NoError(t, client.Chat().Send(testAddress(t).String(), "hello"))
+
+	select {
+	case err := <-gaveUp:
+		assert.ErrorIs(t, err, ErrOutboxGaveUp)
+		assert.ErrorIs(t, err, errSendFailed)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for outbox to give up")
+	}
+	assert.Empty(t, client.Outbox().Pending())
+}
+
+func TestOutboxGivesUpOnRejection(t *testing.T) {
+	transport := newFakeTransport(t)
+	client := newOutboxClient(t, transport, OutboxConfig{
+		Chat:         true,
+		InitialDelay: time.Millisecond,
+		MaxAttempts:  10,
+	})
+
+	gaveUp := make(chan error, 1)
+	client.Outbox().OnGaveUp(func(entry OutboxEntry, err error) {
+		assert.Less(t, entry.Attempts, 10)
+		gaveUp <- err
+	})
+
+	transport.failSends(errSendFailed)
+	require.NoError(t, client.Chat().Send(testAddress(t).String(), "hello"))
+
+	// Middleware added after the first send rejects every retry
+	client.Use(func(next MessageHandler) MessageHandler {
+		return func(mc *MessageContext) error {
+			if mc.Direction == Outbound {
+				return ErrMessageRejected
+			}
+			return next(mc)
+		}
+	})
+
+	select {
+	case err := <-gaveUp:
+		assert.ErrorIs(t, err, ErrMessageRejected)
+		assert.NotErrorIs(t, err, ErrOutboxGaveUp)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for outbox to give up")
+	}
+	assert.Empty(t, client.Outbox().Pending())
+}
+
+func TestOutboxNotificationReportsSent(t *testing.T) {
+	transport := newFakeTransport(t)
+	client := newOutboxClient(t, transport, OutboxConfig{Notifications: true, InitialDelay: 5 * time.Millisecond})
+
+	sent := make(chan *NotificationSummary, 1)
+	client.Notifications().OnNotificationSent(func(peerDID string, summary *NotificationSummary) {
+		sent <- summary
+	})
+
+	transport.failSends(errSendFailed)
+	require.NoError(t, client.Notifications().SendCustomNotification(testAddress(t).String(), "Alert", "System update", "system"))
+	require.Len(t, client.Outbox().Pending(), 1)
+
+	select {
+	case <-sent:
+		t.Fatal("queued notification reported as sent")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// The summary is reported once the outbox delivers the notification
+	transport.failSends(nil)
+
+	select {
+	case summary := <-sent:
+		assert.Equal(t, "Alert", summary.Title)
+		assert.Equal(t, "system", summary.MessageType)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for sent notification")
+	}
+	assert.Empty(t, client.Outbox().Pending())
+}
+
+func TestOutboxDisabled(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	transport.failSends(errSendFailed)
+	assert.ErrorIs(t, client.Chat().Send(testAddress(t).String(), "hello"), errSendFailed)
+	assert.Empty(t, client.Outbox().Pending())
+}
+
+func TestOutboxWaitsForConnection(t *testing.T) {
+	transport := newFakeTransport(t)
+	client := newOutboxClient(t, transport, OutboxConfig{Chat: true, InitialDelay: time.Millisecond})
+
+	transport.callbacks.OnConnect()
+	transport.callbacks.OnDisconnect(errSendFailed)
+
+	transport.failSends(errSendFailed)
+	require.NoError(t, client.Chat().Send(testAddress(t).String(), "hello"))
+	transport.failSends(nil)
+
+	// Nothing is retried while disconnected
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(t, client.Outbox().Pending(), 1)
+
+	transport.callbacks.OnConnect()
+	assert.Eventually(t, func() bool {
+		return len(client.Outbox().Pending()) == 0
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 1, transport.sentCount())
+}
+
+func TestOutboxRestoredAfterRestart(t *testing.T) {
+	transport := newFakeTransport(t)
+	config := OutboxConfig{Chat: true, InitialDelay: time.Hour}
+
+	first := newOutboxClient(t, transport, config)
+	transport.failSends(errSendFailed)
+	require.NoError(t, first.Chat().Send(testAddress(t).String(), "hello"))
+	require.NoError(t, first.Close())
+
+	// The entry survives in storage and is retried by the next client
+	second := newOutboxClient(t, transport, config)
+	pending := second.Outbox().Pending()
+	require.Len(t, pending, 1)
+
+	// Retries pass through the middleware chain
+	var retried []string
+	second.Use(func(next MessageHandler) MessageHandler {
+		return func(mc *MessageContext) error {
+			retried = append(retried, hex.EncodeToString(mc.Content.ID()))
+			return next(mc)
+		}
+	})
+
+	transport.failSends(nil)
+	transport.callbacks.OnConnect()
+	assert.Eventually(t, func() bool {
+		return len(second.Outbox().Pending()) == 0
+	}, time.Second, time.Millisecond)
+	require.Equal(t, 1, transport.sentCount())
+
+	// The restored content is the original, not a copy with a new ID
+	assert.Equal(t, pending[0].ID, hex.EncodeToString(transport.sent[0].ID()))
+	assert.Equal(t, []string{pending[0].ID}, retried)
+}
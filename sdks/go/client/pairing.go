@@ -4,10 +4,9 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
-	"sync"
+	"image"
 	"time"
 
-	"github.com/joinself/self-go-sdk/event"
 	"github.com/joinself/self-go-sdk/identity"
 	"github.com/joinself/self-go-sdk/keypair/signing"
 	"github.com/joinself/self-go-sdk/message"
@@ -19,7 +18,7 @@ type PairingRequest struct {
 	client    *Client
 	content   *message.Content
 	requestID string
-	completer chan *PairingResponse
+	pending   *pendingRequest[*PairingResponse]
 }
 
 // PairingResponse represents an account pairing response
@@ -52,9 +51,8 @@ type Pairing struct {
 	client *Client
 
 	// Event handlers
-	onPairingRequestHandlers  []func(*IncomingPairingRequest)
-	onPairingResponseHandlers []func(*PairingResponse)
-	mu                        sync.RWMutex
+	onPairingRequestHandlers  handlerList[func(*IncomingPairingRequest)]
+	onPairingResponseHandlers handlerList[func(*PairingResponse)]
 }
 
 // newPairing creates a new pairing component
@@ -70,7 +68,7 @@ func (p *Pairing) GetPairingCode() (*PairingCode, error) {
 		return nil, ErrClientClosed
 	}
 
-	code, unpaired, err := p.client.account.SDKPairingCode()
+	code, unpaired, err := p.client.transport.SDKPairingCode()
 	if err != nil {
 		return nil, err
 	}
@@ -89,6 +87,17 @@ func (p *Pairing) RequestPairing(peerDID string, address *signing.PublicKey, rol
 
 // RequestPairingWithTimeout sends a pairing request with a custom timeout
 func (p *Pairing) RequestPairingWithTimeout(peerDID string, address *signing.PublicKey, roles identity.Role, timeout time.Duration) (*PairingRequest, error) {
+	return p.requestPairing(context.Background(), peerDID, address, roles, time.Now().Add(timeout))
+}
+
+// RequestPairingContext sends a pairing request to another account.
+// The request expires at the ctx deadline, or after 5 minutes if ctx has none.
+func (p *Pairing) RequestPairingContext(ctx context.Context, peerDID string, address *signing.PublicKey, roles identity.Role) (*PairingRequest, error) {
+	return p.requestPairing(ctx, peerDID, address, roles, expiresFrom(ctx, 5*time.Minute))
+}
+
+// requestPairing builds and sends a pairing request
+func (p *Pairing) requestPairing(ctx context.Context, peerDID string, address *signing.PublicKey, roles identity.Role, expires time.Time) (*PairingRequest, error) {
 	if p.client.isClosed() {
 		return nil, ErrClientClosed
 	}
@@ -100,7 +109,6 @@ func (p *Pairing) RequestPairingWithTimeout(peerDID string, address *signing.Pub
 	}
 
 	// Build the pairing request
-	expires := time.Now().Add(timeout)
 	content, err := message.NewAccountPairingRequest().
 		Address(address).
 		Roles(roles).
@@ -112,22 +120,24 @@ func (p *Pairing) RequestPairingWithTimeout(peerDID string, address *signing.Pub
 
 	// Create request tracker
 	requestID := hex.EncodeToString(content.ID())
-	completer := make(chan *PairingResponse, 1)
+	pending := newPendingRequest[*PairingResponse](requestID, RequestPairing, peerDID, expires)
 
 	request := &PairingRequest{
 		client:    p.client,
 		content:   content,
 		requestID: requestID,
-		completer: completer,
+		pending:   pending,
 	}
 
 	// Store the request for response matching
-	p.client.storeRequest(requestID, completer)
+	if err := p.client.trackRequest(pending); err != nil {
+		return nil, err
+	}
 
 	// Send the request
-	err = p.client.sendMessage(peerAddress, *content)
+	err = p.client.sendMessageContext(ctx, peerAddress, *content)
 	if err != nil {
-		p.client.loadAndDeleteRequest(requestID) // Clean up on error
+		p.client.cancelRequest(requestID, err) // Clean up on error
 		return nil, err
 	}
 
@@ -136,14 +146,12 @@ func (p *Pairing) RequestPairingWithTimeout(peerDID string, address *signing.Pub
 
 // WaitForResponse waits for a pairing response
 func (pr *PairingRequest) WaitForResponse(ctx context.Context) (*PairingResponse, error) {
-	select {
-	case response := <-pr.completer:
-		return response, nil
-	case <-ctx.Done():
+	response, err := pr.pending.wait(ctx)
+	if err != nil && ctx.Err() != nil {
 		// Clean up the stored request
-		pr.client.loadAndDeleteRequest(pr.requestID)
-		return nil, ctx.Err()
+		pr.client.cancelRequest(pr.requestID, err)
 	}
+	return response, err
 }
 
 // RequestID returns the unique request identifier
@@ -166,7 +174,7 @@ func (ipr *IncomingPairingRequest) RespondWithOperationAndAssets(operation *iden
 
 	// Build the response
 	responseBuilder := message.NewAccountPairingResponse().
-		ResponseTo([]byte(ipr.requestID)).
+		ResponseTo(decodeRequestID(ipr.requestID)).
 		Status(message.ResponseStatusAccepted).
 		Operation(operation)
 
@@ -193,7 +201,7 @@ func (ipr *IncomingPairingRequest) Reject() error {
 
 	// Build the rejection response
 	content, err := message.NewAccountPairingResponse().
-		ResponseTo([]byte(ipr.requestID)).
+		ResponseTo(decodeRequestID(ipr.requestID)).
 		Status(message.ResponseStatusForbidden).
 		Finish()
 	if err != nil {
@@ -253,17 +261,13 @@ func (pr *PairingResponse) Assets() []*object.Object {
 // Event handler registration methods
 
 // OnPairingRequest registers a handler for incoming pairing requests
-func (p *Pairing) OnPairingRequest(handler func(*IncomingPairingRequest)) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	p.onPairingRequestHandlers = append(p.onPairingRequestHandlers, handler)
+func (p *Pairing) OnPairingRequest(handler func(*IncomingPairingRequest)) Unsubscribe {
+	return p.onPairingRequestHandlers.add(handler)
 }
 
 // OnPairingResponse registers a handler for pairing responses
-func (p *Pairing) OnPairingResponse(handler func(*PairingResponse)) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	p.onPairingResponseHandlers = append(p.onPairingResponseHandlers, handler)
+func (p *Pairing) OnPairingResponse(handler func(*PairingResponse)) Unsubscribe {
+	return p.onPairingResponseHandlers.add(handler)
 }
 
 // Internal methods for handling events
@@ -280,7 +284,7 @@ func (p *Pairing) onWelcome(from *signing.PublicKey, groupAddress *signing.Publi
 	// New connection established - no specific action needed
 }
 
-func (p *Pairing) onKeyPackage(from *signing.PublicKey) {
+func (p *Pairing) onKeyPackage(from *signing.PublicKey, groupAddress *signing.PublicKey) {
 	// Key package received - no specific action needed
 }
 
@@ -288,10 +292,11 @@ func (p *Pairing) onIntroduction(from *signing.PublicKey, tokenCount int) {
 	// Introduction received - no specific action needed
 }
 
-func (p *Pairing) onAccountPairingRequest(msg *event.Message) {
+func (p *Pairing) onAccountPairingRequest(msg InboundMessage) {
 	// Decode the account pairing request
 	pairingRequest, err := message.DecodeAccountPairingRequest(msg.Content())
 	if err != nil {
+		p.client.reportMessageError(ComponentPairing, "DecodeAccountPairingRequest", msg, err)
 		return
 	}
 
@@ -306,33 +311,29 @@ func (p *Pairing) onAccountPairingRequest(msg *event.Message) {
 	}
 
 	// Notify handlers
-	p.mu.RLock()
-	handlers := make([]func(*IncomingPairingRequest), len(p.onPairingRequestHandlers))
-	copy(handlers, p.onPairingRequestHandlers)
-	p.mu.RUnlock()
+	handlers := p.onPairingRequestHandlers.snapshot()
 
 	for _, handler := range handlers {
-		go handler(incomingRequest)
+		p.client.runHandler(ComponentPairing, incomingRequest.from, func() { handler(incomingRequest) })
 	}
+
+	p.client.publish(Event{Type: EventPairingRequest, PeerDID: incomingRequest.from, PairingRequest: incomingRequest})
 }
 
-func (p *Pairing) onAccountPairingResponse(msg *event.Message) {
+func (p *Pairing) onAccountPairingResponse(msg InboundMessage) {
 	// Decode the account pairing response
 	pairingResponse, err := message.DecodeAccountPairingResponse(msg.Content())
 	if err != nil {
+		p.client.reportMessageError(ComponentPairing, "DecodeAccountPairingResponse", msg, err)
 		return
 	}
 
 	requestID := hex.EncodeToString(pairingResponse.ResponseTo())
 
 	// Find the waiting request
-	completerInterface, ok := p.client.loadAndDeleteRequest(requestID)
-	if !ok {
-		return
-	}
-
-	completer, ok := completerInterface.(chan *PairingResponse)
-	if !ok {
+	pending, err := resolveRequest[*PairingResponse](p.client, requestID, msg.FromAddress().String())
+	if err != nil {
+		p.client.reportMessageError(ComponentPairing, "MatchRequest", msg, err)
 		return
 	}
 
@@ -345,21 +346,16 @@ func (p *Pairing) onAccountPairingResponse(msg *event.Message) {
 	}
 
 	// Send to waiting request
-	select {
-	case completer <- response:
-	default:
-		// Channel full or closed - ignore
-	}
+	pending.complete(response)
 
 	// Notify subscription handlers
-	p.mu.RLock()
-	handlers := make([]func(*PairingResponse), len(p.onPairingResponseHandlers))
-	copy(handlers, p.onPairingResponseHandlers)
-	p.mu.RUnlock()
+	handlers := p.onPairingResponseHandlers.snapshot()
 
 	for _, handler := range handlers {
-		go handler(response)
+		p.client.runHandler(ComponentPairing, response.from, func() { handler(response) })
 	}
+
+	p.client.publish(Event{Type: EventPairingResponse, PeerDID: response.from, PairingResponse: response})
 }
 
 func (p *Pairing) close() {
@@ -368,18 +364,34 @@ func (p *Pairing) close() {
 
 // Helper functions
 
-// GeneratePairingQR generates a QR code containing the pairing code
+// GeneratePairingQR returns the content of the pairing QR code
 func (p *Pairing) GeneratePairingQR() (string, error) {
 	pairingCode, err := p.GetPairingCode()
 	if err != nil {
 		return "", err
 	}
-
-	// For now, return the code as text
-	// In a real implementation, this would generate an actual QR code
 	return fmt.Sprintf("SELF_PAIRING:%s", pairingCode.Code), nil
 }
 
+// GeneratePairingQRImage renders the pairing QR code as an image
+func (p *Pairing) GeneratePairingQRImage(options QRImageOptions) (image.Image, error) {
+	content, err := p.GeneratePairingQR()
+	if err != nil {
+		return nil, err
+	}
+	return renderQRImage([]byte(content), options)
+}
+
+// GeneratePairingQRPNG renders the pairing QR code as a PNG image of about
+// size pixels square
+func (p *Pairing) GeneratePairingQRPNG(size int) ([]byte, error) {
+	content, err := p.GeneratePairingQR()
+	if err != nil {
+		return nil, err
+	}
+	return renderQRPNG([]byte(content), QRImageOptions{Size: size})
+}
+
 // IsPaired checks if the account is currently paired
 func (p *Pairing) IsPaired() (bool, error) {
 	pairingCode, err := p.GetPairingCode()
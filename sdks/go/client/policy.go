@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultApproveTimeout bounds the ConnectionPolicy.Approve callback
+const defaultApproveTimeout = 10 * time.Second
+
+// ConnectionPolicy decides which peers may connect to the client. It is
+// checked for every incoming welcome and key package before the connection
+// is accepted. Allow and Deny are also checked for every introduction, so
+// tokens from peers outside the lists are never stored, even for connections
+// the client started itself. The zero value accepts every connection.
+type ConnectionPolicy struct {
+	// Allow lists the only peer DIDs that may connect, if set
+	Allow []string
+
+	// Deny lists peer DIDs that may never connect
+	Deny []string
+
+	// Approve is called for peers that pass the lists. Returning false or an
+	// error rejects the connection. It runs on the event path, so it should
+	// return quickly.
+	Approve func(ctx context.Context, peerDID string) (bool, error)
+
+	// ApproveTimeout bounds the Approve callback (default: 10s)
+	ApproveTimeout time.Duration
+
+	// MaxConnections limits the number of connected peers (default: 0, unlimited)
+	MaxConnections int
+}
+
+// ConnectionRejection describes an incoming connection refused by the policy
+type ConnectionRejection struct {
+	// PeerDID is the peer that tried to connect
+	PeerDID string
+
+	// Reason is why the connection was rejected
+	Reason error
+
+	// Time is when the connection was rejected
+	Time time.Time
+}
+
+// check applies the policy to a peer trying to connect
+func (p *ConnectionPolicy) check(peerDID string, connected func(string) bool, count func() int) error {
+	if err := p.checkLists(peerDID); err != nil {
+		return err
+	}
+
+	// Peers that are already connected do not count against the limit
+	if p.MaxConnections > 0 && !connected(peerDID) && count() >= p.MaxConnections {
+		return ErrTooManyConnections
+	}
+
+	if p.Approve != nil {
+		timeout := p.ApproveTimeout
+		if timeout <= 0 {
+			timeout = defaultApproveTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		approved, err := p.Approve(ctx, peerDID)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrPeerNotApproved, err)
+		}
+		if !approved {
+			return ErrPeerNotApproved
+		}
+	}
+
+	return nil
+}
+
+// checkLists applies the deny list and allowlist to a peer
+func (p *ConnectionPolicy) checkLists(peerDID string) error {
+	for _, denied := range p.Deny {
+		if denied == peerDID {
+			return ErrPeerDenied
+		}
+	}
+
+	if len(p.Allow) > 0 {
+		allowed := false
+		for _, did := range p.Allow {
+			if did == peerDID {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return ErrPeerNotAllowed
+		}
+	}
+	return nil
+}
+
+// admitConnection checks the connection policy for a peer, reporting
+// rejections. It reports whether the connection may proceed.
+func (c *Client) admitConnection(peerDID string) bool {
+	// Events delivered while the client is starting are checked without
+	// connection counts
+	connected := func(string) bool { return false }
+	count := func() int { return 0 }
+	if c.connection != nil {
+		connected = c.connection.isConnected
+		count = c.connection.connectedCount
+	}
+
+	err := c.config.ConnectionPolicy.check(peerDID, connected, count)
+	if err == nil {
+		return true
+	}
+	c.rejectConnection(peerDID, err)
+	return false
+}
+
+// admitIntroduction checks a peer's introduction against the deny list and
+// allowlist. Approve and MaxConnections were already applied when the
+// connection was negotiated.
+func (c *Client) admitIntroduction(peerDID string) bool {
+	err := c.config.ConnectionPolicy.checkLists(peerDID)
+	if err == nil {
+		return true
+	}
+	c.rejectConnection(peerDID, err)
+	return false
+}
+
+// rejectConnection logs and reports a peer refused by the connection policy
+func (c *Client) rejectConnection(peerDID string, err error) {
+	c.logger.Info("connection rejected",
+		logKeyComponent, ComponentConnection,
+		logKeyPeer, peerDID,
+		"reason", err,
+	)
+
+	if c.connection != nil {
+		c.connection.onRejected(ConnectionRejection{
+			PeerDID: peerDID,
+			Reason:  err,
+			Time:    time.Now(),
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joinself/self-go-sdk/keypair/signing"
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/joinself/self-go-sdk/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionPolicyCheck(t *testing.T) {
+	notConnected := func(string) bool { return false }
+	connected := func(string) bool { return true }
+	count := func() int { return 2 }
+	errLookup := errors.New("lookup failed")
+
+	tests := []struct {
+		name      string
+		policy    ConnectionPolicy
+		connected func(string) bool
+		want      error
+	}{
+		{"zero value accepts", ConnectionPolicy{}, notConnected, nil},
+		{"denied", ConnectionPolicy{Deny: []string{"did:a"}}, notConnected, ErrPeerDenied},
+		{"deny wins over allow", ConnectionPolicy{Allow: []string{"did:a"}, Deny: []string{"did:a"}}, notConnected, ErrPeerDenied},
+		{"allowed", ConnectionPolicy{Allow: []string{"did:a"}}, notConnected, nil},
+		{"not allowed", ConnectionPolicy{Allow: []string{"did:b"}}, notConnected, ErrPeerNotAllowed},
+		{"limit reached", ConnectionPolicy{MaxConnections: 2}, notConnected, ErrTooManyConnections},
+		{"limit ignores connected peers", ConnectionPolicy{MaxConnections: 2}, connected, nil},
+		{"under limit", ConnectionPolicy{MaxConnections: 3}, notConnected, nil},
+		{"approved", ConnectionPolicy{Approve: func(context.Context, string) (bool, error) { return true, nil }}, notConnected, nil},
+		{"not approved", ConnectionPolicy{Approve: func(context.Context, string) (bool, error) { return false, nil }}, notConnected, ErrPeerNotApproved},
+		{"approve error", ConnectionPolicy{Approve: func(context.Context, string) (bool, error) { return false, errLookup }}, notConnected, errLookup},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.check("did:a", tt.connected, count)
+			if tt.want == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectionPolicyApproveContext(t *testing.T) {
+	var peer string
+	policy := ConnectionPolicy{
+		Approve: func(ctx context.Context, peerDID string) (bool, error) {
+			peer = peerDID
+			_, hasDeadline := ctx.Deadline()
+			return hasDeadline, nil
+		},
+	}
+
+	require.NoError(t, policy.check("did:a", func(string) bool { return false }, func() int { return 0 }))
+	assert.Equal(t, "did:a", peer)
+}
+
+func TestConnectionRejected(t *testing.T) {
+	denied := testAddress(t)
+	allowed := testAddress(t)
+
+	transport := newFakeTransport(t)
+	client, err := New(Config{
+		Transport:        transport.factory(),
+		ConnectionPolicy: ConnectionPolicy{Deny: []string{denied.String()}},
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := client.Events(ctx, EventFilter{Types: []EventType{EventConnectionRejected}})
+
+	rejections := make(chan ConnectionRejection, 1)
+	client.Connection().OnConnectionRejected(func(rejection ConnectionRejection) {
+		rejections <- rejection
+	})
+
+	transport.callbacks.OnKeyPackage(denied, transport.address, nil)
+	transport.callbacks.OnKeyPackage(allowed, transport.address, nil)
+
+	event := nextEvent(t, events)
+	assert.Equal(t, denied.String(), event.PeerDID)
+	require.NotNil(t, event.Rejection)
+	assert.ErrorIs(t, event.Cause, ErrPeerDenied)
+
+	rejection := <-rejections
+	assert.Equal(t, denied.String(), rejection.PeerDID)
+	assert.ErrorIs(t, rejection.Reason, ErrPeerDenied)
+
+	assert.False(t, client.Connection().isConnected(denied.String()))
+	assert.True(t, client.Connection().isConnected(allowed.String()))
+}
+
+func TestIntroductionRejected(t *testing.T) {
+	allowed := testAddress(t)
+	stranger := testAddress(t)
+
+	transport := newFakeTransport(t)
+	client, err := New(Config{
+		Transport:        transport.factory(),
+		ConnectionPolicy: ConnectionPolicy{Allow: []string{allowed.String()}},
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := client.Events(ctx, EventFilter{Types: []EventType{EventConnectionRejected, EventPeerConnected}})
+
+	introduce := func(peer *signing.PublicKey) {
+		introduction, err := message.NewIntroduction().
+			DocumentAddress(peer).
+			Token(new(token.Token)).
+			Finish()
+		require.NoError(t, err)
+		transport.receive(peer, introduction)
+	}
+
+	// Peers outside the allowlist cannot hand over tokens, even for
+	// connections this client started
+	introduce(stranger)
+	event := nextEvent(t, events)
+	assert.Equal(t, EventConnectionRejected, event.Type)
+	assert.Equal(t, stranger.String(), event.PeerDID)
+	assert.ErrorIs(t, event.Cause, ErrPeerNotAllowed)
+	assert.Equal(t, 0, transport.tokenCount())
+
+	transport.callbacks.OnWelcome(allowed, transport.address, nil)
+	introduce(allowed)
+	event = nextEvent(t, events)
+	assert.Equal(t, EventPeerConnected, event.Type)
+	assert.Equal(t, allowed.String(), event.PeerDID)
+	assert.Equal(t, 1, transport.tokenCount())
+	assert.False(t, client.Connection().isConnected(stranger.String()))
+}
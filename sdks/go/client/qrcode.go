@@ -0,0 +1,150 @@
+package client
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	defaultQRImageSize = 256
+	defaultQRMargin    = 4
+)
+
+// ErrorCorrectionLevel is how much of a rendered QR code can be damaged
+// and still be read. Higher levels make denser codes.
+type ErrorCorrectionLevel int
+
+const (
+	ErrorCorrectionDefault  ErrorCorrectionLevel = iota // Medium
+	ErrorCorrectionLow                                  // Recovers about 7% of the code
+	ErrorCorrectionMedium                               // Recovers about 15% of the code
+	ErrorCorrectionQuartile                             // Recovers about 25% of the code
+	ErrorCorrectionHigh                                 // Recovers about 30% of the code
+)
+
+// String returns the error correction level name
+func (l ErrorCorrectionLevel) String() string {
+	switch l {
+	case ErrorCorrectionDefault:
+		return "default"
+	case ErrorCorrectionLow:
+		return "low"
+	case ErrorCorrectionMedium:
+		return "medium"
+	case ErrorCorrectionQuartile:
+		return "quartile"
+	case ErrorCorrectionHigh:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// QRImageOptions controls how QR codes are rendered as images
+type QRImageOptions struct {
+	// Size is the width and height of the image in pixels (default: 256).
+	// Codes with more modules than pixels are rendered one pixel per module.
+	Size int
+
+	// ErrorCorrection is the error correction level (default: medium)
+	ErrorCorrection ErrorCorrectionLevel
+
+	// Margin is the quiet zone around the code in modules (default: 4)
+	Margin int
+
+	// NoMargin renders the code without a quiet zone, ignoring Margin
+	NoMargin bool
+}
+
+// recoveryLevel returns the encoder's equivalent of the level. go-qrcode
+// names the four standard levels Low, Medium, High and Highest, so its High
+// is the standard quartile level and its Highest the standard high level.
+func (l ErrorCorrectionLevel) recoveryLevel() qrcode.RecoveryLevel {
+	switch l {
+	case ErrorCorrectionLow:
+		return qrcode.Low
+	case ErrorCorrectionQuartile:
+		return qrcode.High // Level Q, about 25%
+	case ErrorCorrectionHigh:
+		return qrcode.Highest // Level H, about 30%
+	default:
+		return qrcode.Medium
+	}
+}
+
+// margin returns the quiet zone width in modules
+func (o QRImageOptions) margin() int {
+	switch {
+	case o.NoMargin:
+		return 0
+	case o.Margin <= 0:
+		return defaultQRMargin
+	default:
+		return o.Margin
+	}
+}
+
+// renderQRImage encodes data as a QR code image
+func renderQRImage(data []byte, options QRImageOptions) (image.Image, error) {
+	code, err := qrcode.New(string(data), options.ErrorCorrection.recoveryLevel())
+	if err != nil {
+		// Data that does not fit the largest version is the only failure
+		return nil, ErrQRCodeTooLarge
+	}
+	code.DisableBorder = true
+	bitmap := code.Bitmap()
+
+	size := options.Size
+	if size <= 0 {
+		size = defaultQRImageSize
+	}
+
+	margin := options.margin()
+	modules := len(bitmap) + 2*margin
+	scale := size / modules
+	if scale < 1 {
+		scale = 1
+	}
+	if size < modules*scale {
+		size = modules * scale
+	}
+
+	// Center the code, leaving any remainder as extra quiet zone
+	offset := (size-modules*scale)/2 + margin*scale
+
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetGray(offset+x*scale+dx, offset+y*scale+dy, color.Gray{Y: 0})
+				}
+			}
+		}
+	}
+	return img, nil
+}
+
+// renderQRPNG encodes data as a PNG QR code image
+func renderQRPNG(data []byte, options QRImageOptions) ([]byte, error) {
+	img, err := renderQRImage(data, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderQRLevels(t *testing.T) {
+	// Version 1 is 21 modules
+	low, err := renderQRImage([]byte("SELF_PAIRING:123456"), QRImageOptions{Size: 1, NoMargin: true, ErrorCorrection: ErrorCorrectionLow})
+	require.NoError(t, err)
+	assert.Equal(t, 21, low.Bounds().Dx())
+
+	// Higher levels need larger symbols
+	high, err := renderQRImage([]byte("SELF_PAIRING:123456"), QRImageOptions{Size: 1, NoMargin: true, ErrorCorrection: ErrorCorrectionHigh})
+	require.NoError(t, err)
+	assert.Greater(t, high.Bounds().Dx(), low.Bounds().Dx())
+
+	// The zero value renders at medium
+	unset, err := renderQRImage([]byte("SELF_PAIRING:123456"), QRImageOptions{Size: 1, NoMargin: true})
+	require.NoError(t, err)
+	medium, err := renderQRImage([]byte("SELF_PAIRING:123456"), QRImageOptions{Size: 1, NoMargin: true, ErrorCorrection: ErrorCorrectionMedium})
+	require.NoError(t, err)
+	assert.Equal(t, medium, unset)
+	assert.Equal(t, ErrorCorrectionMedium.recoveryLevel(), ErrorCorrectionDefault.recoveryLevel())
+
+	_, err = renderQRImage(make([]byte, 3000), QRImageOptions{ErrorCorrection: ErrorCorrectionLow})
+	assert.ErrorIs(t, err, ErrQRCodeTooLarge)
+}
+
+func TestRenderQRPNG(t *testing.T) {
+	data, err := renderQRPNG([]byte("hello"), QRImageOptions{Size: 200})
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 200, img.Bounds().Dx())
+	assert.Equal(t, 200, img.Bounds().Dy())
+
+	// Version 1 is 21 modules plus 8 of margin, so each module is 6 pixels
+	// and the code is centered 13 pixels in
+	r, _, _, _ := img.At(13+4*6-1, 13+4*6-1).RGBA()
+	assert.Equal(t, uint32(0xffff), r, "quiet zone")
+	r, _, _, _ = img.At(13+4*6, 13+4*6).RGBA()
+	assert.Equal(t, uint32(0), r, "finder")
+
+	// Without a margin the finder starts at the first pixel
+	bare, err := renderQRImage([]byte("hello"), QRImageOptions{Size: 21, NoMargin: true})
+	require.NoError(t, err)
+	r, _, _, _ = bare.At(0, 0).RGBA()
+	assert.Equal(t, uint32(0), r)
+
+	// Small sizes still render one pixel per module
+	small, err := renderQRImage([]byte("hello"), QRImageOptions{Size: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 29, small.Bounds().Dx())
+}
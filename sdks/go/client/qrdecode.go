@@ -0,0 +1,472 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// qrCode is a QR code symbol being read, with a map of the function
+// patterns that hold no data
+type qrCode struct {
+	version  int
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// Error correction codewords per block and number of blocks, indexed by
+// level (low, medium, quartile, high) and version
+var (
+	qrECCodewordsPerBlock = [4][41]int{
+		{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+		{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	}
+	qrECBlocks = [4][41]int{
+		{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+		{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+		{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+		{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+	}
+)
+
+// qrLevelIndex maps a level to its row in the block tables
+func qrLevelIndex(level ErrorCorrectionLevel) int {
+	switch level {
+	case ErrorCorrectionLow:
+		return 0
+	case ErrorCorrectionQuartile:
+		return 2
+	case ErrorCorrectionHigh:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// qrFormatBits returns the two bit level indicator used in format information
+func qrFormatBits(level ErrorCorrectionLevel) int {
+	switch level {
+	case ErrorCorrectionLow:
+		return 1
+	case ErrorCorrectionQuartile:
+		return 3
+	case ErrorCorrectionHigh:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// qrFormatInfo returns the 15 bit format information for a level and mask
+func qrFormatInfo(level ErrorCorrectionLevel, mask int) int {
+	data := qrFormatBits(level)<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// qrRawDataModules returns the number of modules available for data and
+// error correction in a version
+func qrRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		alignments := version/7 + 2
+		result -= (25*alignments-10)*alignments - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+// newQRCode creates a symbol of the given version with its function
+// patterns marked
+func newQRCode(version int) *qrCode {
+	size := version*4 + 17
+	code := &qrCode{
+		version:  version,
+		size:     size,
+		modules:  make([][]bool, size),
+		function: make([][]bool, size),
+	}
+	for i := range code.modules {
+		code.modules[i] = make([]bool, size)
+		code.function[i] = make([]bool, size)
+	}
+
+	// Timing patterns
+	for i := 0; i < size; i++ {
+		code.function[6][i] = true
+		code.function[i][6] = true
+	}
+
+	// Finder patterns with separators and the format areas beside them
+	code.reserve(0, 0, 9, 9)
+	code.reserve(size-8, 0, 8, 9)
+	code.reserve(0, size-8, 9, 8)
+
+	// Alignment patterns, except where they would overlap finders
+	positions := code.alignmentPositions()
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			code.reserve(x-2, y-2, 5, 5)
+		}
+	}
+
+	// Version information
+	if version >= 7 {
+		code.reserve(size-11, 0, 3, 6)
+		code.reserve(0, size-11, 6, 3)
+	}
+	return code
+}
+
+// reserve marks a rectangle of modules as function patterns
+func (c *qrCode) reserve(x, y, width, height int) {
+	for dy := 0; dy < height; dy++ {
+		for dx := 0; dx < width; dx++ {
+			c.function[y+dy][x+dx] = true
+		}
+	}
+}
+
+// alignmentPositions returns the centers of alignment patterns on each axis
+func (c *qrCode) alignmentPositions() []int {
+	if c.version == 1 {
+		return nil
+	}
+	count := c.version/7 + 2
+	step := (c.version*8 + count*3 + 5) / (count*4 - 4) * 2
+	positions := make([]int, count)
+	positions[0] = 6
+	for i, pos := count-1, c.size-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// forEachDataModule visits the modules outside function patterns in the
+// zigzag order codewords are placed
+func (c *qrCode) forEachDataModule(visit func(x, y int)) {
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			// Skip the vertical timing pattern
+			right = 5
+		}
+		for vert := 0; vert < c.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.size - 1 - vert
+				}
+				if !c.function[y][x] {
+					visit(x, y)
+				}
+			}
+		}
+	}
+}
+
+// applyMask toggles the data modules selected by a mask pattern
+func (c *qrCode) applyMask(mask int) {
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if c.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			c.modules[y][x] = c.modules[y][x] != invert
+		}
+	}
+}
+
+// qrBitBuffer is a sequence of bits, most significant first
+type qrBitBuffer []bool
+
+// append adds the low n bits of value
+func (b *qrBitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 != 0)
+	}
+}
+
+// decodeQRText reads the data of a QR code drawn as Unicode text, with each
+// character holding two modules stacked vertically. Either color may be used
+// for dark modules. The text is assumed undamaged, so error correction
+// codewords are not checked.
+func decodeQRText(text string) ([]byte, error) {
+	var grid [][]bool
+	for _, line := range strings.Split(strings.TrimRight(text, "\r\n"), "\n") {
+		var top, bottom []bool
+		for _, r := range strings.TrimRight(line, "\r") {
+			switch r {
+			case ' ':
+				top, bottom = append(top, false), append(bottom, false)
+			case '▀':
+				top, bottom = append(top, true), append(bottom, false)
+			case '▄':
+				top, bottom = append(top, false), append(bottom, true)
+			case '█':
+				top, bottom = append(top, true), append(bottom, true)
+			default:
+				return nil, ErrInvalidQRCode
+			}
+		}
+		grid = append(grid, top, bottom)
+	}
+
+	for _, dark := range []bool{true, false} {
+		if modules := findQRSymbol(grid, dark); modules != nil {
+			if data, err := decodeQRModules(modules); err == nil {
+				return data, nil
+			}
+		}
+	}
+	return nil, ErrInvalidQRCode
+}
+
+// findQRSymbol crops the quiet zone from a grid, treating cells equal to
+// dark as dark modules
+func findQRSymbol(grid [][]bool, dark bool) [][]bool {
+	// The top row of a symbol runs between the outer edges of two finders
+	minX, minY, maxX := -1, -1, -1
+	for y, row := range grid {
+		for x, cell := range row {
+			if cell != dark {
+				continue
+			}
+			if minX < 0 {
+				minX = x
+			}
+			maxX = x
+		}
+		if minX >= 0 {
+			minY = y
+			break
+		}
+	}
+
+	size := maxX - minX + 1
+	if minX < 0 || size < 21 || (size-17)%4 != 0 || minY+size > len(grid) {
+		return nil
+	}
+
+	modules := make([][]bool, size)
+	for y := range modules {
+		modules[y] = make([]bool, size)
+		row := grid[minY+y]
+		for x := range modules[y] {
+			modules[y][x] = minX+x < len(row) && row[minX+x] == dark
+		}
+	}
+	return modules
+}
+
+// decodeQRModules reads the data of an undamaged QR code symbol
+func decodeQRModules(modules [][]bool) ([]byte, error) {
+	size := len(modules)
+	version := (size - 17) / 4
+	if version < 1 || version > 40 || size != version*4+17 {
+		return nil, ErrInvalidQRCode
+	}
+
+	// Format information around the top left finder
+	format := 0
+	bit := func(x, y int) {
+		format <<= 1
+		if modules[y][x] {
+			format |= 1
+		}
+	}
+	for i := 14; i >= 9; i-- {
+		bit(14-i, 8)
+	}
+	bit(7, 8)
+	bit(8, 8)
+	bit(8, 7)
+	for i := 5; i >= 0; i-- {
+		bit(8, i)
+	}
+
+	level, mask := ErrorCorrectionDefault, -1
+	for _, l := range []ErrorCorrectionLevel{ErrorCorrectionLow, ErrorCorrectionMedium, ErrorCorrectionQuartile, ErrorCorrectionHigh} {
+		for m := 0; m < 8; m++ {
+			if qrFormatInfo(l, m) == format {
+				level, mask = l, m
+			}
+		}
+	}
+	if mask < 0 {
+		return nil, ErrInvalidQRCode
+	}
+
+	code := newQRCode(version)
+	for y := range modules {
+		copy(code.modules[y], modules[y])
+	}
+	code.applyMask(mask)
+
+	var bits qrBitBuffer
+	code.forEachDataModule(func(x, y int) {
+		bits = append(bits, code.modules[y][x])
+	})
+	codewords := make([]byte, qrRawDataModules(version)/8)
+	for i := range codewords {
+		for _, b := range bits[i*8 : i*8+8] {
+			codewords[i] <<= 1
+			if b {
+				codewords[i] |= 1
+			}
+		}
+	}
+
+	// Collect the data codewords of each block, undoing the interleaving
+	l := qrLevelIndex(level)
+	blocks := qrECBlocks[l][version]
+	ecLen := qrECCodewordsPerBlock[l][version]
+	shortBlocks := blocks - len(codewords)%blocks
+	shortData := len(codewords)/blocks - ecLen
+
+	data := make([][]byte, blocks)
+	k := 0
+	for i := 0; i <= shortData; i++ {
+		for j := range data {
+			if i < shortData || j >= shortBlocks {
+				data[j] = append(data[j], codewords[k])
+				k++
+			}
+		}
+	}
+
+	var stream qrBitBuffer
+	for _, block := range data {
+		for _, b := range block {
+			stream.append(int(b), 8)
+		}
+	}
+	return stream.readSegments(version)
+}
+
+// readSegments decodes numeric, alphanumeric and byte mode segments
+func (b qrBitBuffer) readSegments(version int) ([]byte, error) {
+	pos := 0
+	read := func(n int) (int, bool) {
+		if pos+n > len(b) {
+			return 0, false
+		}
+		value := 0
+		for _, bit := range b[pos : pos+n] {
+			value <<= 1
+			if bit {
+				value |= 1
+			}
+		}
+		pos += n
+		return value, true
+	}
+
+	// Character count widths for versions 1-9, 10-26 and 27-40
+	group := 0
+	if version > 26 {
+		group = 2
+	} else if version > 9 {
+		group = 1
+	}
+	const alphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+	var data []byte
+	for {
+		mode, ok := read(4)
+		if !ok || mode == 0 {
+			return data, nil
+		}
+
+		switch mode {
+		case 0x1:
+			count, ok := read([]int{10, 12, 14}[group])
+			for ok && count > 0 {
+				digits := min(count, 3)
+				var value int
+				value, ok = read([]int{0, 4, 7, 10}[digits])
+				ok = ok && value < []int{0, 10, 100, 1000}[digits]
+				data = append(data, fmt.Sprintf("%0*d", digits, value)...)
+				count -= digits
+			}
+			if !ok {
+				return nil, ErrInvalidQRCode
+			}
+		case 0x2:
+			count, ok := read([]int{9, 11, 13}[group])
+			for ok && count > 0 {
+				var value int
+				if count == 1 {
+					value, ok = read(6)
+					ok = ok && value < 45
+					data = append(data, alphanumeric[value%45])
+					count--
+					continue
+				}
+				value, ok = read(11)
+				ok = ok && value < 45*45
+				data = append(data, alphanumeric[value/45%45], alphanumeric[value%45])
+				count -= 2
+			}
+			if !ok {
+				return nil, ErrInvalidQRCode
+			}
+		case 0x4:
+			count, ok := read([]int{8, 16, 16}[group])
+			for ok && count > 0 {
+				var value int
+				if value, ok = read(8); ok {
+					data = append(data, byte(value))
+					count--
+				}
+			}
+			if !ok {
+				return nil, ErrInvalidQRCode
+			}
+		case 0x7:
+			// ECI designators only change the character set; the bytes are kept
+			first, ok := read(8)
+			switch {
+			case ok && first&0xc0 == 0x80:
+				_, ok = read(8)
+			case ok && first&0xe0 == 0xc0:
+				_, ok = read(16)
+			}
+			if !ok {
+				return nil, ErrInvalidQRCode
+			}
+		default:
+			return nil, ErrInvalidQRCode
+		}
+	}
+}
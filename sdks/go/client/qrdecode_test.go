@@ -0,0 +1,60 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeQRText(t *testing.T) {
+	payloads := []string{
+		"discovery request \x00\x01\x02",
+		"SELF PAIRING 123456",
+		string(make([]byte, 600)),
+	}
+	levels := []qrcode.RecoveryLevel{qrcode.Low, qrcode.Medium, qrcode.High, qrcode.Highest}
+	for _, payload := range payloads {
+		for _, level := range levels {
+			code, err := qrcode.New(payload, level)
+			require.NoError(t, err)
+
+			// Dark modules may be drawn as filled or empty cells
+			for _, inverted := range []bool{false, true} {
+				decoded, err := decodeQRText(code.ToSmallString(inverted))
+				require.NoError(t, err, "version %d level %d inverted %v", code.VersionNumber, level, inverted)
+				assert.Equal(t, payload, string(decoded))
+			}
+		}
+	}
+
+	_, err := decodeQRText("not a QR code")
+	assert.ErrorIs(t, err, ErrInvalidQRCode)
+	_, err = decodeQRText("██▀▀\n▄▄  ")
+	assert.ErrorIs(t, err, ErrInvalidQRCode)
+}
+
+func TestReadQRSegments(t *testing.T) {
+	// Alphanumeric "HE", numeric "12345" and byte "!" segments
+	var bits qrBitBuffer
+	bits.append(0x2, 4)
+	bits.append(2, 9)
+	bits.append(17*45+14, 11)
+	bits.append(0x1, 4)
+	bits.append(5, 10)
+	bits.append(123, 10)
+	bits.append(45, 7)
+	bits.append(0x4, 4)
+	bits.append(1, 8)
+	bits.append('!', 8)
+	bits.append(0, 4)
+
+	data, err := bits.readSegments(1)
+	require.NoError(t, err)
+	assert.Equal(t, "HE12345!", string(data))
+
+	// Truncated segments are rejected
+	_, err = bits[:20].readSegments(1)
+	assert.ErrorIs(t, err, ErrInvalidQRCode)
+}
@@ -0,0 +1,347 @@
+package client
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// requestSweepInterval is how often expired requests are removed
+var requestSweepInterval = 30 * time.Second
+
+// RequestKind identifies the type of an outgoing request
+type RequestKind int
+
+const (
+	RequestDiscovery RequestKind = iota
+	RequestCredentialPresentation
+	RequestCredentialVerification
+	RequestPairing
+)
+
+// String returns the request kind name
+func (k RequestKind) String() string {
+	switch k {
+	case RequestDiscovery:
+		return "discovery"
+	case RequestCredentialPresentation:
+		return "credential_presentation"
+	case RequestCredentialVerification:
+		return "credential_verification"
+	case RequestPairing:
+		return "pairing"
+	default:
+		return "unknown"
+	}
+}
+
+// PendingRequest describes an outgoing request that is waiting for a response
+type PendingRequest struct {
+	// ID is the hex encoded request ID
+	ID string
+
+	// Kind is the type of request
+	Kind RequestKind
+
+	// PeerDID is the peer the request was sent to (empty for discovery requests)
+	PeerDID string
+
+	// CreatedAt is when the request was registered
+	CreatedAt time.Time
+
+	// ExpiresAt is when the request expires
+	ExpiresAt time.Time
+}
+
+// trackedRequest is a pending request held by the registry
+type trackedRequest interface {
+	info() PendingRequest
+	fail(err error)
+}
+
+// pendingRequest tracks a single outgoing request and delivers its response
+// or failure to any number of waiters
+type pendingRequest[T any] struct {
+	details  PendingRequest
+	done     chan struct{}
+	once     sync.Once
+	response T
+	err      error
+}
+
+// newPendingRequest creates a pending request of the given kind
+func newPendingRequest[T any](id string, kind RequestKind, peerDID string, expires time.Time) *pendingRequest[T] {
+	return &pendingRequest[T]{
+		details: PendingRequest{
+			ID:        id,
+			Kind:      kind,
+			PeerDID:   peerDID,
+			CreatedAt: time.Now(),
+			ExpiresAt: expires,
+		},
+		done: make(chan struct{}),
+	}
+}
+
+func (r *pendingRequest[T]) info() PendingRequest {
+	return r.details
+}
+
+// complete delivers the response to waiters
+func (r *pendingRequest[T]) complete(response T) {
+	r.once.Do(func() {
+		r.response = response
+		close(r.done)
+	})
+}
+
+// fail releases waiters with an error
+func (r *pendingRequest[T]) fail(err error) {
+	r.once.Do(func() {
+		r.err = err
+		close(r.done)
+	})
+}
+
+// wait blocks until the request completes, fails, or ctx is done
+func (r *pendingRequest[T]) wait(ctx context.Context) (T, error) {
+	select {
+	case <-r.done:
+		return r.response, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// requestRegistry holds the client's pending requests
+type requestRegistry struct {
+	requests map[string]trackedRequest
+	aliases  map[string]string // other IDs a request answers to
+	closed   bool
+	mu       sync.Mutex
+}
+
+// newRequestRegistry creates an empty registry
+func newRequestRegistry() *requestRegistry {
+	return &requestRegistry{
+		requests: make(map[string]trackedRequest),
+		aliases:  make(map[string]string),
+	}
+}
+
+// resolveLocked returns the ID a request is tracked under
+func (r *requestRegistry) resolveLocked(requestID string) string {
+	if id, ok := r.aliases[requestID]; ok {
+		return id
+	}
+	return requestID
+}
+
+// deleteLocked stops tracking a request and its aliases
+func (r *requestRegistry) deleteLocked(requestID string) {
+	delete(r.requests, requestID)
+	for alias, id := range r.aliases {
+		if id == requestID {
+			delete(r.aliases, alias)
+		}
+	}
+}
+
+// add registers a request, failing if the registry is closed
+func (r *requestRegistry) add(request trackedRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrClientClosed
+	}
+	r.requests[request.info().ID] = request
+	return nil
+}
+
+// remove stops tracking a request and returns it
+func (r *requestRegistry) remove(requestID string) (trackedRequest, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	requestID = r.resolveLocked(requestID)
+	request, ok := r.requests[requestID]
+	if ok {
+		r.deleteLocked(requestID)
+	}
+	return request, ok
+}
+
+// removeFrom stops tracking a request answered by sender. A request sent to
+// a specific peer stays tracked if the response came from anyone else.
+func (r *requestRegistry) removeFrom(requestID, sender string) (trackedRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	requestID = r.resolveLocked(requestID)
+	request, ok := r.requests[requestID]
+	if !ok {
+		return nil, ErrRequestNotFound
+	}
+	if peerDID := request.info().PeerDID; peerDID != "" && peerDID != sender {
+		return nil, ErrInvalidResponse
+	}
+	r.deleteLocked(requestID)
+	return request, nil
+}
+
+// alias makes a tracked request also answer to aliasID until it is removed,
+// reporting false if it is no longer tracked
+func (r *requestRegistry) alias(requestID, aliasID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	requestID = r.resolveLocked(requestID)
+	if _, ok := r.requests[requestID]; !ok {
+		return false
+	}
+	r.aliases[aliasID] = requestID
+	return true
+}
+
+// lookup returns a request without removing it
+func (r *requestRegistry) lookup(requestID string) (trackedRequest, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	request, ok := r.requests[r.resolveLocked(requestID)]
+	return request, ok
+}
+
+// list returns all pending requests, oldest first
+func (r *requestRegistry) list() []PendingRequest {
+	r.mu.Lock()
+	pending := make([]PendingRequest, 0, len(r.requests))
+	for _, request := range r.requests {
+		pending = append(pending, request.info())
+	}
+	r.mu.Unlock()
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+	return pending
+}
+
+// sweep removes and fails requests that expired before now
+func (r *requestRegistry) sweep(now time.Time) []PendingRequest {
+	r.mu.Lock()
+	var expired []trackedRequest
+	for id, request := range r.requests {
+		expires := request.info().ExpiresAt
+		if !expires.IsZero() && now.After(expires) {
+			expired = append(expired, request)
+			r.deleteLocked(id)
+		}
+	}
+	r.mu.Unlock()
+
+	swept := make([]PendingRequest, len(expired))
+	for i, request := range expired {
+		request.fail(ErrRequestExpired)
+		swept[i] = request.info()
+	}
+	return swept
+}
+
+// cancelPeer removes and fails the requests sent to a peer
+func (r *requestRegistry) cancelPeer(peerDID string, err error) []PendingRequest {
+	r.mu.Lock()
+	var cancelled []trackedRequest
+	for id, request := range r.requests {
+		if request.info().PeerDID == peerDID {
+			cancelled = append(cancelled, request)
+			r.deleteLocked(id)
+		}
+	}
+	r.mu.Unlock()
+
+	infos := make([]PendingRequest, len(cancelled))
+	for i, request := range cancelled {
+		request.fail(err)
+		infos[i] = request.info()
+	}
+	return infos
+}
+
+// close fails every pending request and rejects new ones
+func (r *requestRegistry) close(err error) {
+	r.mu.Lock()
+	r.closed = true
+	requests := r.requests
+	r.requests = make(map[string]trackedRequest)
+	r.aliases = make(map[string]string)
+	r.mu.Unlock()
+
+	for _, request := range requests {
+		request.fail(err)
+	}
+}
+
+// PendingRequests returns the outgoing requests still waiting for a response
+func (c *Client) PendingRequests() []PendingRequest {
+	return c.requests.list()
+}
+
+// trackRequest registers an outgoing request
+func (c *Client) trackRequest(request trackedRequest) error {
+	if err := c.requests.add(request); err != nil {
+		return err
+	}
+	info := request.info()
+	c.logger.Debug("request registered",
+		logKeyRequestID, info.ID,
+		logKeyPeer, info.PeerDID,
+		"kind", info.Kind.String(),
+	)
+	return nil
+}
+
+// resolveRequest stops tracking a request answered by sender and returns it
+// with its response type
+func resolveRequest[T any](c *Client, requestID, sender string) (*pendingRequest[T], error) {
+	request, err := c.requests.removeFrom(requestID, sender)
+	if err != nil {
+		return nil, err
+	}
+
+	typed, ok := request.(*pendingRequest[T])
+	if !ok {
+		request.fail(ErrInvalidResponse)
+		return nil, ErrInvalidResponse
+	}
+
+	c.logger.Debug("request resolved", logKeyRequestID, requestID)
+	return typed, nil
+}
+
+// cancelRequest stops tracking a request that will not receive a response
+func (c *Client) cancelRequest(requestID string, err error) {
+	if request, ok := c.requests.remove(requestID); ok {
+		request.fail(err)
+		c.logger.Debug("request cancelled", logKeyRequestID, requestID, "error", err)
+	}
+}
+
+// sweepRequests periodically fails expired requests until the client closes
+func (c *Client) sweepRequests() {
+	ticker := time.NewTicker(requestSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case now := <-ticker.C:
+			for _, expired := range c.requests.sweep(now) {
+				c.logger.Debug("request expired",
+					logKeyRequestID, expired.ID,
+					logKeyPeer, expired.PeerDID,
+					"kind", expired.Kind.String(),
+				)
+			}
+		}
+	}
+}
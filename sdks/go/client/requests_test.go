@@ -0,0 +1,189 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingRequests(t *testing.T) {
+	client, transport := newTestClient(t)
+	peer := testAddress(t)
+
+	qr, err := client.Discovery().GenerateQR()
+	require.NoError(t, err)
+
+	req, err := client.Credentials().RequestVerification(peer.String(), []string{"VerifiableCredential"})
+	require.NoError(t, err)
+
+	pending := client.PendingRequests()
+	require.Len(t, pending, 2)
+	assert.Equal(t, qr.RequestID(), pending[0].ID)
+	assert.Equal(t, RequestDiscovery, pending[0].Kind)
+	assert.Equal(t, req.RequestID(), pending[1].ID)
+	assert.Equal(t, RequestCredentialVerification, pending[1].Kind)
+	assert.Equal(t, peer.String(), pending[1].PeerDID)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), pending[1].ExpiresAt, time.Second)
+
+	// A response resolves the request and removes it from the registry
+	requestID, err := hex.DecodeString(qr.RequestID())
+	require.NoError(t, err)
+
+	response, err := message.NewDiscoveryResponse().
+		ResponseTo(requestID).
+		Status(message.ResponseStatusAccepted).
+		Finish()
+	require.NoError(t, err)
+	transport.receive(peer, response)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	discovered, err := qr.WaitForResponse(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, peer.String(), discovered.DID())
+
+	pending = client.PendingRequests()
+	require.Len(t, pending, 1)
+	assert.Equal(t, req.RequestID(), pending[0].ID)
+}
+
+func TestPendingRequestsFailOnClose(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	qr, err := client.Discovery().GenerateQR()
+	require.NoError(t, err)
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := qr.WaitForResponse(context.Background())
+		result <- err
+	}()
+
+	require.NoError(t, client.Close())
+
+	select {
+	case err := <-result:
+		assert.ErrorIs(t, err, ErrClientClosed)
+	case <-time.After(time.Second):
+		t.Fatal("waiter was not released on close")
+	}
+
+	assert.Empty(t, client.PendingRequests())
+}
+
+func TestPendingRequestsSweep(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	qr, err := client.Discovery().GenerateQRWithTimeout(time.Millisecond)
+	require.NoError(t, err)
+
+	swept := client.requests.sweep(time.Now().Add(time.Second))
+	require.Len(t, swept, 1)
+	assert.Equal(t, qr.RequestID(), swept[0].ID)
+	assert.Empty(t, client.PendingRequests())
+
+	_, err = qr.WaitForResponse(context.Background())
+	assert.ErrorIs(t, err, ErrRequestExpired)
+}
+
+func TestRequestAliases(t *testing.T) {
+	registry := newRequestRegistry()
+	request := newPendingRequest[*Peer]("original", RequestDiscovery, "", time.Now().Add(time.Minute))
+	require.NoError(t, registry.add(request))
+
+	assert.True(t, registry.alias("original", "second"))
+	assert.True(t, registry.alias("second", "third"))
+	assert.False(t, registry.alias("unknown", "fourth"))
+
+	// Every alias resolves to the request, which is listed once
+	for _, id := range []string{"original", "second", "third"} {
+		found, ok := registry.lookup(id)
+		require.True(t, ok)
+		assert.Same(t, request, found)
+	}
+	assert.Len(t, registry.list(), 1)
+
+	// Removing the request by any ID forgets all of them
+	_, ok := registry.remove("third")
+	assert.True(t, ok)
+	for _, id := range []string{"original", "second", "third"} {
+		_, ok := registry.lookup(id)
+		assert.False(t, ok)
+	}
+	assert.Empty(t, registry.aliases)
+}
+
+func TestWaitForResponseCancelRemovesRequest(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	qr, err := client.Discovery().GenerateQR()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = qr.WaitForResponse(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, client.PendingRequests())
+}
+
+func TestResponseFromOtherPeerKeepsRequest(t *testing.T) {
+	client, transport := newTestClient(t)
+	peer := testAddress(t)
+	other := testAddress(t)
+
+	events := make(chan ErrorEvent, 1)
+	client.OnError(func(event ErrorEvent) {
+		events <- event
+	})
+
+	req, err := client.Credentials().RequestVerification(peer.String(), []string{"VerifiableCredential"})
+	require.NoError(t, err)
+
+	requestID, err := hex.DecodeString(req.RequestID())
+	require.NoError(t, err)
+
+	response, err := message.NewCredentialVerificationResponse().
+		ResponseTo(requestID).
+		Status(message.ResponseStatusAccepted).
+		Finish()
+	require.NoError(t, err)
+
+	// A response from anyone but the peer is reported and ignored
+	transport.receive(other, response)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "MatchRequest", event.Operation)
+		assert.Equal(t, other.String(), event.PeerDID)
+		assert.ErrorIs(t, event, ErrInvalidResponse)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error event")
+	}
+
+	pending := client.PendingRequests()
+	require.Len(t, pending, 1)
+	assert.Equal(t, req.RequestID(), pending[0].ID)
+
+	// The peer's own response still resolves the request
+	response, err = message.NewCredentialVerificationResponse().
+		ResponseTo(requestID).
+		Status(message.ResponseStatusAccepted).
+		Finish()
+	require.NoError(t, err)
+	transport.receive(peer, response)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	resolved, err := req.WaitForResponse(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, peer.String(), resolved.From())
+	assert.Empty(t, client.PendingRequests())
+}
@@ -0,0 +1,122 @@
+package client
+
+import "context"
+
+// stateDispatchKey orders state change handlers relative to each other
+const stateDispatchKey = "\x00state"
+
+// ConnectionState is the client's connection to the Self network
+type ConnectionState int
+
+const (
+	// StateConnecting is the initial state, until the transport first connects
+	StateConnecting ConnectionState = iota
+
+	// StateConnected means the transport is connected
+	StateConnected
+
+	// StateDisconnected means the connection was lost
+	StateDisconnected
+
+	// StateClosed means the client has been closed
+	StateClosed
+)
+
+// String returns the state name
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// StateChange describes a transition between connection states
+type StateChange struct {
+	From ConnectionState
+	To   ConnectionState
+
+	// Err is the cause of the transition, if any
+	Err error
+}
+
+// State returns the client's current connection state
+func (c *Client) State() ConnectionState {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.state
+}
+
+// OnStateChange registers a handler for connection state transitions.
+// Handlers are called in the order the transitions happened.
+func (c *Client) OnStateChange(handler func(StateChange)) Unsubscribe {
+	return c.stateHandlers.add(handler)
+}
+
+// WaitUntilConnected blocks until the client is connected. It returns
+// ErrClientClosed if the client is closed first, or the ctx error.
+func (c *Client) WaitUntilConnected(ctx context.Context) error {
+	for {
+		c.stateMu.Lock()
+		state, changed := c.state, c.stateChanged
+		c.stateMu.Unlock()
+
+		switch state {
+		case StateConnected:
+			return nil
+		case StateClosed:
+			return ErrClientClosed
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// setState moves the client to a new state and notifies handlers.
+// It reports whether the state changed.
+func (c *Client) setState(state ConnectionState, err error) bool {
+	c.stateMu.Lock()
+	change, changed := c.transitionLocked(state, err)
+	c.stateMu.Unlock()
+
+	if changed {
+		c.notifyStateChange(change)
+	}
+	return changed
+}
+
+// transitionLocked changes the state with stateMu held. Closed clients stay closed.
+func (c *Client) transitionLocked(state ConnectionState, err error) (StateChange, bool) {
+	change := StateChange{From: c.state, To: state, Err: err}
+	if c.state == state || c.state == StateClosed {
+		return change, false
+	}
+	c.state = state
+	close(c.stateChanged)
+	c.stateChanged = make(chan struct{})
+	return change, true
+}
+
+// notifyStateChange logs a transition and queues the state change handlers
+func (c *Client) notifyStateChange(change StateChange) {
+	c.logger.Debug("connection state changed",
+		logKeyComponent, ComponentClient,
+		"from", change.From.String(),
+		"to", change.To.String(),
+	)
+
+	for _, handler := range c.stateHandlers.snapshot() {
+		c.runHandler(ComponentClient, stateDispatchKey, func() { handler(change) })
+	}
+}
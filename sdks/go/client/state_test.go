@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateTransitions(t *testing.T) {
+	client, transport := newTestClient(t)
+	assert.Equal(t, StateConnecting, client.State())
+
+	changes := make(chan StateChange, 10)
+	client.OnStateChange(func(change StateChange) {
+		changes <- change
+	})
+
+	cause := errors.New("connection reset")
+	transport.callbacks.OnConnect()
+	transport.callbacks.OnDisconnect(cause)
+	require.NoError(t, client.Close())
+
+	expected := []StateChange{
+		{From: StateConnecting, To: StateConnected},
+		{From: StateConnected, To: StateDisconnected, Err: cause},
+		{From: StateDisconnected, To: StateClosed},
+	}
+	for _, want := range expected {
+		select {
+		case change := <-changes:
+			assert.Equal(t, want, change)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for state change")
+		}
+	}
+
+	// Closed clients stay closed
+	transport.callbacks.OnConnect()
+	assert.Equal(t, StateClosed, client.State())
+}
+
+func TestWaitUntilConnected(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, client.WaitUntilConnected(ctx), context.DeadlineExceeded)
+
+	go transport.callbacks.OnConnect()
+	require.NoError(t, client.WaitUntilConnected(context.Background()))
+
+	require.NoError(t, client.Close())
+	assert.ErrorIs(t, client.WaitUntilConnected(context.Background()), ErrClientClosed)
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -33,62 +34,108 @@ func newStorage(client *Client) *Storage {
 
 // Store stores a value with the given key
 func (s *Storage) Store(key string, value []byte) error {
+	return s.StoreContext(context.Background(), key, value)
+}
+
+// StoreContext stores a value with the given key, giving up when ctx is done
+func (s *Storage) StoreContext(ctx context.Context, key string, value []byte) error {
 	if s.client.isClosed() {
 		return ErrClientClosed
 	}
 
-	return s.client.account.ValueStore(key, value)
+	return runContext(ctx, func() error {
+		return s.client.transport.ValueStore(key, value)
+	})
 }
 
 // StoreWithExpiry stores a value with the given key and expiry time
 func (s *Storage) StoreWithExpiry(key string, value []byte, expires time.Time) error {
+	return s.StoreWithExpiryContext(context.Background(), key, value, expires)
+}
+
+// StoreWithExpiryContext stores a value with an expiry time, giving up when ctx is done
+func (s *Storage) StoreWithExpiryContext(ctx context.Context, key string, value []byte, expires time.Time) error {
 	if s.client.isClosed() {
 		return ErrClientClosed
 	}
 
-	return s.client.account.ValueStoreWithExpiry(key, value, expires)
+	return runContext(ctx, func() error {
+		return s.client.transport.ValueStoreWithExpiry(key, value, expires)
+	})
 }
 
 // StoreString stores a string value
 func (s *Storage) StoreString(key, value string) error {
-	return s.Store(key, []byte(value))
+	return s.StoreStringContext(context.Background(), key, value)
+}
+
+// StoreStringContext stores a string value, giving up when ctx is done
+func (s *Storage) StoreStringContext(ctx context.Context, key, value string) error {
+	return s.StoreContext(ctx, key, []byte(value))
 }
 
 // StoreStringWithExpiry stores a string value with expiry
 func (s *Storage) StoreStringWithExpiry(key, value string, expires time.Time) error {
-	return s.StoreWithExpiry(key, []byte(value), expires)
+	return s.StoreStringWithExpiryContext(context.Background(), key, value, expires)
+}
+
+// StoreStringWithExpiryContext stores a string value with expiry, giving up when ctx is done
+func (s *Storage) StoreStringWithExpiryContext(ctx context.Context, key, value string, expires time.Time) error {
+	return s.StoreWithExpiryContext(ctx, key, []byte(value), expires)
 }
 
 // StoreJSON stores a JSON-serializable value
 func (s *Storage) StoreJSON(key string, value interface{}) error {
+	return s.StoreJSONContext(context.Background(), key, value)
+}
+
+// StoreJSONContext stores a JSON-serializable value, giving up when ctx is done
+func (s *Storage) StoreJSONContext(ctx context.Context, key string, value interface{}) error {
 	data, err := json.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON: %w", err)
 	}
-	return s.Store(key, data)
+	return s.StoreContext(ctx, key, data)
 }
 
 // StoreJSONWithExpiry stores a JSON-serializable value with expiry
 func (s *Storage) StoreJSONWithExpiry(key string, value interface{}, expires time.Time) error {
+	return s.StoreJSONWithExpiryContext(context.Background(), key, value, expires)
+}
+
+// StoreJSONWithExpiryContext stores a JSON-serializable value with expiry, giving up when ctx is done
+func (s *Storage) StoreJSONWithExpiryContext(ctx context.Context, key string, value interface{}, expires time.Time) error {
 	data, err := json.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON: %w", err)
 	}
-	return s.StoreWithExpiry(key, data, expires)
+	return s.StoreWithExpiryContext(ctx, key, data, expires)
 }
 
 // Lookup retrieves a value by key
 func (s *Storage) Lookup(key string) ([]byte, error) {
+	return s.LookupContext(context.Background(), key)
+}
+
+// LookupContext retrieves a value by key, giving up when ctx is done
+func (s *Storage) LookupContext(ctx context.Context, key string) ([]byte, error) {
 	if s.client.isClosed() {
 		return nil, ErrClientClosed
 	}
 
-	return s.client.account.ValueLookup(key)
+	return lookupContext(ctx, func() ([]byte, error) {
+		return s.client.transport.ValueLookup(key)
+	})
 }
 
 // LookupString retrieves a string value by key
 func (s *Storage) LookupString(key string) (string, error) {
-	data, err := s.Lookup(key)
+	return s.LookupStringContext(context.Background(), key)
+}
+
+// LookupStringContext retrieves a string value by key, giving up when ctx is done
+func (s *Storage) LookupStringContext(ctx context.Context, key string) (string, error) {
+	data, err := s.LookupContext(ctx, key)
 	if err != nil {
 		return "", err
 	}
@@ -97,7 +144,12 @@ func (s *Storage) LookupString(key string) (string, error) {
 
 // LookupJSON retrieves and unmarshals a JSON value by key
 func (s *Storage) LookupJSON(key string, target interface{}) error {
-	data, err := s.Lookup(key)
+	return s.LookupJSONContext(context.Background(), key, target)
+}
+
+// LookupJSONContext retrieves and unmarshals a JSON value by key, giving up when ctx is done
+func (s *Storage) LookupJSONContext(ctx context.Context, key string, target interface{}) error {
+	data, err := s.LookupContext(ctx, key)
 	if err != nil {
 		return err
 	}
@@ -107,35 +159,62 @@ func (s *Storage) LookupJSON(key string, target interface{}) error {
 
 // Exists checks if a key exists in storage
 func (s *Storage) Exists(key string) bool {
-	_, err := s.Lookup(key)
+	return s.ExistsContext(context.Background(), key)
+}
+
+// ExistsContext checks if a key exists in storage, giving up when ctx is done
+func (s *Storage) ExistsContext(ctx context.Context, key string) bool {
+	_, err := s.LookupContext(ctx, key)
 	return err == nil
 }
 
 // Delete removes a value by key
 func (s *Storage) Delete(key string) error {
+	return s.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext removes a value by key, giving up when ctx is done
+func (s *Storage) DeleteContext(ctx context.Context, key string) error {
 	if s.client.isClosed() {
 		return ErrClientClosed
 	}
 
-	return s.client.account.ValueRemove(key)
+	return runContext(ctx, func() error {
+		return s.client.transport.ValueRemove(key)
+	})
 }
 
 // StoreTemporary stores a value with a relative expiry duration
 func (s *Storage) StoreTemporary(key string, value []byte, duration time.Duration) error {
+	return s.StoreTemporaryContext(context.Background(), key, value, duration)
+}
+
+// StoreTemporaryContext stores a value with a relative expiry duration, giving up when ctx is done
+func (s *Storage) StoreTemporaryContext(ctx context.Context, key string, value []byte, duration time.Duration) error {
 	expires := time.Now().Add(duration)
-	return s.StoreWithExpiry(key, value, expires)
+	return s.StoreWithExpiryContext(ctx, key, value, expires)
 }
 
 // StoreTemporaryString stores a string value with a relative expiry duration
 func (s *Storage) StoreTemporaryString(key, value string, duration time.Duration) error {
+	return s.StoreTemporaryStringContext(context.Background(), key, value, duration)
+}
+
+// StoreTemporaryStringContext stores a string value with a relative expiry duration, giving up when ctx is done
+func (s *Storage) StoreTemporaryStringContext(ctx context.Context, key, value string, duration time.Duration) error {
 	expires := time.Now().Add(duration)
-	return s.StoreStringWithExpiry(key, value, expires)
+	return s.StoreStringWithExpiryContext(ctx, key, value, expires)
 }
 
 // StoreTemporaryJSON stores a JSON value with a relative expiry duration
 func (s *Storage) StoreTemporaryJSON(key string, value interface{}, duration time.Duration) error {
+	return s.StoreTemporaryJSONContext(context.Background(), key, value, duration)
+}
+
+// StoreTemporaryJSONContext stores a JSON value with a relative expiry duration, giving up when ctx is done
+func (s *Storage) StoreTemporaryJSONContext(ctx context.Context, key string, value interface{}, duration time.Duration) error {
 	expires := time.Now().Add(duration)
-	return s.StoreJSONWithExpiry(key, value, expires)
+	return s.StoreJSONWithExpiryContext(ctx, key, value, expires)
 }
 
 // StorageNamespace provides namespaced storage operations
@@ -159,57 +238,112 @@ func (sn *StorageNamespace) namespacedKey(key string) string {
 
 // Store stores a value in the namespace
 func (sn *StorageNamespace) Store(key string, value []byte) error {
-	return sn.storage.Store(sn.namespacedKey(key), value)
+	return sn.StoreContext(context.Background(), key, value)
+}
+
+// StoreContext stores a value in the namespace, giving up when ctx is done
+func (sn *StorageNamespace) StoreContext(ctx context.Context, key string, value []byte) error {
+	return sn.storage.StoreContext(ctx, sn.namespacedKey(key), value)
 }
 
 // StoreWithExpiry stores a value with expiry in the namespace
 func (sn *StorageNamespace) StoreWithExpiry(key string, value []byte, expires time.Time) error {
-	return sn.storage.StoreWithExpiry(sn.namespacedKey(key), value, expires)
+	return sn.StoreWithExpiryContext(context.Background(), key, value, expires)
+}
+
+// StoreWithExpiryContext stores a value with expiry in the namespace, giving up when ctx is done
+func (sn *StorageNamespace) StoreWithExpiryContext(ctx context.Context, key string, value []byte, expires time.Time) error {
+	return sn.storage.StoreWithExpiryContext(ctx, sn.namespacedKey(key), value, expires)
 }
 
 // StoreString stores a string value in the namespace
 func (sn *StorageNamespace) StoreString(key, value string) error {
-	return sn.storage.StoreString(sn.namespacedKey(key), value)
+	return sn.StoreStringContext(context.Background(), key, value)
+}
+
+// StoreStringContext stores a string value in the namespace, giving up when ctx is done
+func (sn *StorageNamespace) StoreStringContext(ctx context.Context, key, value string) error {
+	return sn.storage.StoreStringContext(ctx, sn.namespacedKey(key), value)
 }
 
 // StoreJSON stores a JSON value in the namespace
 func (sn *StorageNamespace) StoreJSON(key string, value interface{}) error {
-	return sn.storage.StoreJSON(sn.namespacedKey(key), value)
+	return sn.StoreJSONContext(context.Background(), key, value)
+}
+
+// StoreJSONContext stores a JSON value in the namespace, giving up when ctx is done
+func (sn *StorageNamespace) StoreJSONContext(ctx context.Context, key string, value interface{}) error {
+	return sn.storage.StoreJSONContext(ctx, sn.namespacedKey(key), value)
 }
 
 // Lookup retrieves a value from the namespace
 func (sn *StorageNamespace) Lookup(key string) ([]byte, error) {
-	return sn.storage.Lookup(sn.namespacedKey(key))
+	return sn.LookupContext(context.Background(), key)
+}
+
+// LookupContext retrieves a value from the namespace, giving up when ctx is done
+func (sn *StorageNamespace) LookupContext(ctx context.Context, key string) ([]byte, error) {
+	return sn.storage.LookupContext(ctx, sn.namespacedKey(key))
 }
 
 // LookupString retrieves a string value from the namespace
 func (sn *StorageNamespace) LookupString(key string) (string, error) {
-	return sn.storage.LookupString(sn.namespacedKey(key))
+	return sn.LookupStringContext(context.Background(), key)
+}
+
+// LookupStringContext retrieves a string value from the namespace, giving up when ctx is done
+func (sn *StorageNamespace) LookupStringContext(ctx context.Context, key string) (string, error) {
+	return sn.storage.LookupStringContext(ctx, sn.namespacedKey(key))
 }
 
 // LookupJSON retrieves a JSON value from the namespace
 func (sn *StorageNamespace) LookupJSON(key string, target interface{}) error {
-	return sn.storage.LookupJSON(sn.namespacedKey(key), target)
+	return sn.LookupJSONContext(context.Background(), key, target)
+}
+
+// LookupJSONContext retrieves a JSON value from the namespace, giving up when ctx is done
+func (sn *StorageNamespace) LookupJSONContext(ctx context.Context, key string, target interface{}) error {
+	return sn.storage.LookupJSONContext(ctx, sn.namespacedKey(key), target)
 }
 
 // Exists checks if a key exists in the namespace
 func (sn *StorageNamespace) Exists(key string) bool {
-	return sn.storage.Exists(sn.namespacedKey(key))
+	return sn.ExistsContext(context.Background(), key)
+}
+
+// ExistsContext checks if a key exists in the namespace, giving up when ctx is done
+func (sn *StorageNamespace) ExistsContext(ctx context.Context, key string) bool {
+	return sn.storage.ExistsContext(ctx, sn.namespacedKey(key))
 }
 
 // Delete removes a value from the namespace
 func (sn *StorageNamespace) Delete(key string) error {
-	return sn.storage.Delete(sn.namespacedKey(key))
+	return sn.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext removes a value from the namespace, giving up when ctx is done
+func (sn *StorageNamespace) DeleteContext(ctx context.Context, key string) error {
+	return sn.storage.DeleteContext(ctx, sn.namespacedKey(key))
 }
 
 // StoreTemporary stores a value with relative expiry in the namespace
 func (sn *StorageNamespace) StoreTemporary(key string, value []byte, duration time.Duration) error {
-	return sn.storage.StoreTemporary(sn.namespacedKey(key), value, duration)
+	return sn.StoreTemporaryContext(context.Background(), key, value, duration)
+}
+
+// StoreTemporaryContext stores a value with relative expiry in the namespace, giving up when ctx is done
+func (sn *StorageNamespace) StoreTemporaryContext(ctx context.Context, key string, value []byte, duration time.Duration) error {
+	return sn.storage.StoreTemporaryContext(ctx, sn.namespacedKey(key), value, duration)
 }
 
 // StoreJSONWithExpiry stores a JSON value with expiry in the namespace
 func (sn *StorageNamespace) StoreJSONWithExpiry(key string, value interface{}, expires time.Time) error {
-	return sn.storage.StoreJSONWithExpiry(sn.namespacedKey(key), value, expires)
+	return sn.StoreJSONWithExpiryContext(context.Background(), key, value, expires)
+}
+
+// StoreJSONWithExpiryContext stores a JSON value with expiry in the namespace, giving up when ctx is done
+func (sn *StorageNamespace) StoreJSONWithExpiryContext(ctx context.Context, key string, value interface{}, expires time.Time) error {
+	return sn.storage.StoreJSONWithExpiryContext(ctx, sn.namespacedKey(key), value, expires)
 }
 
 // Cache provides caching functionality with automatic expiry
@@ -233,37 +367,67 @@ func (c *Cache) cacheKey(key string) string {
 
 // Set stores a value in the cache with default expiry (1 hour)
 func (c *Cache) Set(key string, value []byte) error {
-	return c.SetWithTTL(key, value, time.Hour)
+	return c.SetContext(context.Background(), key, value)
+}
+
+// SetContext stores a value in the cache with default expiry (1 hour), giving up when ctx is done
+func (c *Cache) SetContext(ctx context.Context, key string, value []byte) error {
+	return c.SetWithTTLContext(ctx, key, value, time.Hour)
 }
 
 // SetWithTTL stores a value in the cache with custom TTL
 func (c *Cache) SetWithTTL(key string, value []byte, ttl time.Duration) error {
+	return c.SetWithTTLContext(context.Background(), key, value, ttl)
+}
+
+// SetWithTTLContext stores a value in the cache with custom TTL, giving up when ctx is done
+func (c *Cache) SetWithTTLContext(ctx context.Context, key string, value []byte, ttl time.Duration) error {
 	expires := time.Now().Add(ttl)
-	return c.storage.StoreWithExpiry(c.cacheKey(key), value, expires)
+	return c.storage.StoreWithExpiryContext(ctx, c.cacheKey(key), value, expires)
 }
 
 // SetString stores a string value in the cache
 func (c *Cache) SetString(key, value string) error {
-	return c.Set(key, []byte(value))
+	return c.SetStringContext(context.Background(), key, value)
+}
+
+// SetStringContext stores a string value in the cache, giving up when ctx is done
+func (c *Cache) SetStringContext(ctx context.Context, key, value string) error {
+	return c.SetContext(ctx, key, []byte(value))
 }
 
 // SetJSON stores a JSON value in the cache
 func (c *Cache) SetJSON(key string, value interface{}) error {
+	return c.SetJSONContext(context.Background(), key, value)
+}
+
+// SetJSONContext stores a JSON value in the cache, giving up when ctx is done
+func (c *Cache) SetJSONContext(ctx context.Context, key string, value interface{}) error {
 	data, err := json.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON: %w", err)
 	}
-	return c.Set(key, data)
+	return c.SetContext(ctx, key, data)
 }
 
 // Get retrieves a value from the cache
 func (c *Cache) Get(key string) ([]byte, error) {
-	return c.storage.Lookup(c.cacheKey(key))
+	return c.GetContext(context.Background(), key)
+}
+
+// GetContext retrieves a value from the cache, giving up when ctx is done
+func (c *Cache) GetContext(ctx context.Context, key string) ([]byte, error) {
+	return c.storage.LookupContext(ctx, c.cacheKey(key))
 }
 
 // GetString retrieves a string value from the cache
 func (c *Cache) GetString(key string) (string, error) {
-	data, err := c.Get(key)
+	return c.GetStringContext(context.Background(), key)
+}
+
+// GetStringContext retrieves a string value from the cache, giving up when ctx is done
+func (c *Cache) GetStringContext(ctx context.Context, key string) (string, error) {
+	data, err := c.GetContext(ctx, key)
 	if err != nil {
 		return "", err
 	}
@@ -272,7 +436,12 @@ func (c *Cache) GetString(key string) (string, error) {
 
 // GetJSON retrieves a JSON value from the cache
 func (c *Cache) GetJSON(key string, target interface{}) error {
-	data, err := c.Get(key)
+	return c.GetJSONContext(context.Background(), key, target)
+}
+
+// GetJSONContext retrieves a JSON value from the cache, giving up when ctx is done
+func (c *Cache) GetJSONContext(ctx context.Context, key string, target interface{}) error {
+	data, err := c.GetContext(ctx, key)
 	if err != nil {
 		return err
 	}
@@ -281,12 +450,22 @@ func (c *Cache) GetJSON(key string, target interface{}) error {
 
 // Has checks if a key exists in the cache
 func (c *Cache) Has(key string) bool {
-	return c.storage.Exists(c.cacheKey(key))
+	return c.HasContext(context.Background(), key)
+}
+
+// HasContext checks if a key exists in the cache, giving up when ctx is done
+func (c *Cache) HasContext(ctx context.Context, key string) bool {
+	return c.storage.ExistsContext(ctx, c.cacheKey(key))
 }
 
 // Delete removes a value from the cache
 func (c *Cache) Delete(key string) error {
-	return c.storage.Delete(c.cacheKey(key))
+	return c.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext removes a value from the cache, giving up when ctx is done
+func (c *Cache) DeleteContext(ctx context.Context, key string) error {
+	return c.storage.DeleteContext(ctx, c.cacheKey(key))
 }
 
 // Internal methods for handling events
@@ -303,7 +482,7 @@ func (s *Storage) onWelcome(from *signing.PublicKey, groupAddress *signing.Publi
 	// New connection established - no specific action needed
 }
 
-func (s *Storage) onKeyPackage(from *signing.PublicKey) {
+func (s *Storage) onKeyPackage(from *signing.PublicKey, groupAddress *signing.PublicKey) {
 	// Key package received - no specific action needed
 }
 
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"time"
+
+	"github.com/joinself/self-go-sdk/account"
+	"github.com/joinself/self-go-sdk/credential"
+	"github.com/joinself/self-go-sdk/crypto"
+	"github.com/joinself/self-go-sdk/event"
+	"github.com/joinself/self-go-sdk/keypair/signing"
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/joinself/self-go-sdk/object"
+	"github.com/joinself/self-go-sdk/token"
+)
+
+// Transport is the backend a Client runs on. It covers every account
+// operation used by the client and its components. *account.Account
+// implements it directly; alternative backends can be plugged in through
+// Config.Transport for testing or custom deployments.
+type Transport interface {
+	// InboxOpen opens a new inbox and returns its address
+	InboxOpen() (*signing.PublicKey, error)
+
+	// MessageSend sends message content to a peer
+	MessageSend(to *signing.PublicKey, content *message.Content) error
+
+	// ConnectionNegotiate starts negotiating a connection with a peer
+	ConnectionNegotiate(asAddress, withAddress *signing.PublicKey, expires time.Time) error
+
+	// ConnectionNegotiateOutOfBand creates a key package for out-of-band negotiation
+	ConnectionNegotiateOutOfBand(asAddress *signing.PublicKey, expires time.Time) (*crypto.KeyPackage, error)
+
+	// ConnectionAccept accepts a welcome and returns the group address
+	ConnectionAccept(asAddress *signing.PublicKey, welcome *crypto.Welcome) (*signing.PublicKey, error)
+
+	// ConnectionEstablish establishes a connection from a key package
+	ConnectionEstablish(asAddress *signing.PublicKey, keyPackage *crypto.KeyPackage) (*signing.PublicKey, error)
+
+	// TokenStore stores a token received from a peer
+	TokenStore(fromAddress, toAddress, forAddress *signing.PublicKey, tkn *token.Token) error
+
+	// ValueStore stores a value in encrypted storage
+	ValueStore(key string, value []byte) error
+
+	// ValueStoreWithExpiry stores a value in encrypted storage until it expires
+	ValueStoreWithExpiry(key string, value []byte, expires time.Time) error
+
+	// ValueLookup retrieves a value from encrypted storage
+	ValueLookup(key string) ([]byte, error)
+
+	// ValueRemove removes a value from encrypted storage
+	ValueRemove(key string) error
+
+	// ObjectUpload uploads an encrypted object to the object store
+	ObjectUpload(obj *object.Object, persistLocal bool) error
+
+	// ObjectDownload downloads and decrypts an object from the object store
+	ObjectDownload(obj *object.Object) error
+
+	// CredentialIssue signs and issues a credential
+	CredentialIssue(unsignedCredential *credential.Credential) (*credential.VerifiableCredential, error)
+
+	// PresentationIssue signs and issues a presentation
+	PresentationIssue(unsignedPresentation *credential.Presentation) (*credential.VerifiablePresentation, error)
+
+	// NotificationSend sends a push notification to a peer
+	NotificationSend(to *signing.PublicKey, summary *message.ContentSummary) error
+
+	// SDKPairingCode returns the SDK pairing code and whether the account is unpaired
+	SDKPairingCode() (string, bool, error)
+}
+
+// TokenRemover is implemented by transports that can delete the tokens
+// received from a peer. Connection.Disconnect uses it to revoke a peer.
+type TokenRemover interface {
+	TokenRemove(fromAddress, toAddress *signing.PublicKey) error
+}
+
+// TransportCallbacks are the events a Transport delivers to the client
+type TransportCallbacks struct {
+	OnConnect    func()
+	OnDisconnect func(err error)
+	OnWelcome    func(from, to *signing.PublicKey, welcome *crypto.Welcome)
+	OnKeyPackage func(from, to *signing.PublicKey, keyPackage *crypto.KeyPackage)
+	OnMessage    func(msg InboundMessage)
+}
+
+// TransportFactory creates a transport that delivers its events to the given callbacks
+type TransportFactory func(config *Config, callbacks TransportCallbacks) (Transport, error)
+
+// InboundMessage is a message received from a peer. *event.Message
+// implements it; custom transports may supply their own implementation.
+type InboundMessage interface {
+	ID() []byte
+	FromAddress() *signing.PublicKey
+	ToAddress() *signing.PublicKey
+	Content() *message.Content
+}
+
+// Ensure the Self account and its messages satisfy the transport interfaces
+var (
+	_ Transport      = (*account.Account)(nil)
+	_ InboundMessage = (*event.Message)(nil)
+)
+
+// newAccountTransport creates a transport backed by a Self account
+func newAccountTransport(config *Config, callbacks TransportCallbacks) (Transport, error) {
+	accountConfig := config.toAccountConfig()
+	accountConfig.Callbacks = account.Callbacks{
+		OnConnect: func(*account.Account) {
+			callbacks.OnConnect()
+		},
+		OnDisconnect: func(_ *account.Account, err error) {
+			callbacks.OnDisconnect(err)
+		},
+		OnWelcome: func(_ *account.Account, wlc *event.Welcome) {
+			callbacks.OnWelcome(wlc.FromAddress(), wlc.ToAddress(), wlc.Welcome())
+		},
+		OnKeyPackage: func(_ *account.Account, kp *event.KeyPackage) {
+			callbacks.OnKeyPackage(kp.FromAddress(), kp.ToAddress(), kp.KeyPackage())
+		},
+		OnMessage: func(_ *account.Account, msg *event.Message) {
+			callbacks.OnMessage(msg)
+		},
+	}
+
+	acc, err := account.New(accountConfig)
+	if err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
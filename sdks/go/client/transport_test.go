@@ -0,0 +1,240 @@
+package client
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joinself/self-go-sdk/credential"
+	"github.com/joinself/self-go-sdk/crypto"
+	"github.com/joinself/self-go-sdk/keypair/signing"
+	"github.com/joinself/self-go-sdk/message"
+	"github.com/joinself/self-go-sdk/object"
+	"github.com/joinself/self-go-sdk/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTransport is a minimal in-memory transport that records sent messages
+type fakeTransport struct {
+	address   *signing.PublicKey
+	callbacks TransportCallbacks
+	values    map[string][]byte
+	sent      []*message.Content
+	tokens    int
+	removed   int
+	sendErr   error
+	mu        sync.Mutex
+}
+
+func newFakeTransport(t *testing.T) *fakeTransport {
+	return &fakeTransport{
+		address: testAddress(t),
+		values:  make(map[string][]byte),
+	}
+}
+
+// factory returns a TransportFactory that hands out this transport
+func (f *fakeTransport) factory() TransportFactory {
+	return func(config *Config, callbacks TransportCallbacks) (Transport, error) {
+		f.callbacks = callbacks
+		return f, nil
+	}
+}
+
+func (f *fakeTransport) InboxOpen() (*signing.PublicKey, error) {
+	return f.address, nil
+}
+
+func (f *fakeTransport) MessageSend(to *signing.PublicKey, content *message.Content) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.sendErr != nil {
+		return f.sendErr
+	}
+	f.sent = append(f.sent, content)
+	return nil
+}
+
+func (f *fakeTransport) ConnectionNegotiate(asAddress, withAddress *signing.PublicKey, expires time.Time) error {
+	return nil
+}
+
+func (f *fakeTransport) ConnectionNegotiateOutOfBand(asAddress *signing.PublicKey, expires time.Time) (*crypto.KeyPackage, error) {
+	return nil, nil
+}
+
+func (f *fakeTransport) ConnectionAccept(asAddress *signing.PublicKey, welcome *crypto.Welcome) (*signing.PublicKey, error) {
+	return asAddress, nil
+}
+
+func (f *fakeTransport) ConnectionEstablish(asAddress *signing.PublicKey, keyPackage *crypto.KeyPackage) (*signing.PublicKey, error) {
+	return asAddress, nil
+}
+
+func (f *fakeTransport) TokenStore(fromAddress, toAddress, forAddress *signing.PublicKey, tkn *token.Token) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokens++
+	return nil
+}
+
+func (f *fakeTransport) TokenRemove(fromAddress, toAddress *signing.PublicKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.removed++
+	return nil
+}
+
+func (f *fakeTransport) ValueStore(key string, value []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[key] = value
+	return nil
+}
+
+func (f *fakeTransport) ValueStoreWithExpiry(key string, value []byte, expires time.Time) error {
+	return f.ValueStore(key, value)
+}
+
+func (f *fakeTransport) ValueLookup(key string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.values[key]
+	if !ok {
+		return nil, ErrRequestNotFound
+	}
+	return value, nil
+}
+
+func (f *fakeTransport) ValueRemove(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.values, key)
+	return nil
+}
+
+func (f *fakeTransport) ObjectUpload(obj *object.Object, persistLocal bool) error {
+	return nil
+}
+
+func (f *fakeTransport) ObjectDownload(obj *object.Object) error {
+	return nil
+}
+
+func (f *fakeTransport) CredentialIssue(unsignedCredential *credential.Credential) (*credential.VerifiableCredential, error) {
+	return nil, nil
+}
+
+func (f *fakeTransport) PresentationIssue(unsignedPresentation *credential.Presentation) (*credential.VerifiablePresentation, error) {
+	return nil, nil
+}
+
+func (f *fakeTransport) NotificationSend(to *signing.PublicKey, summary *message.ContentSummary) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sendErr
+}
+
+func (f *fakeTransport) SDKPairingCode() (string, bool, error) {
+	return "", true, nil
+}
+
+// failSends makes sends fail with err until called with nil
+func (f *fakeTransport) failSends(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sendErr = err
+}
+
+// sentCount returns the number of messages sent through the transport
+func (f *fakeTransport) sentCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sent)
+}
+
+// tokenCount returns the number of tokens stored through the transport
+func (f *fakeTransport) tokenCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tokens
+}
+
+// testMessage is an inbound message injected directly into a client
+type testMessage struct {
+	from    *signing.PublicKey
+	to      *signing.PublicKey
+	content *message.Content
+}
+
+func (m *testMessage) ID() []byte                      { return m.content.ID() }
+func (m *testMessage) FromAddress() *signing.PublicKey { return m.from }
+func (m *testMessage) ToAddress() *signing.PublicKey   { return m.to }
+func (m *testMessage) Content() *message.Content       { return m.content }
+
+// receive delivers content to the client as if it was sent by from
+func (f *fakeTransport) receive(from *signing.PublicKey, content *message.Content) {
+	f.callbacks.OnMessage(&testMessage{
+		from:    from,
+		to:      f.address,
+		content: content,
+	})
+}
+
+// receiveOn delivers content to the client as if it was sent by from to the
+// given address, such as a group
+func (f *fakeTransport) receiveOn(from, to *signing.PublicKey, content *message.Content) {
+	f.callbacks.OnMessage(&testMessage{
+		from:    from,
+		to:      to,
+		content: content,
+	})
+}
+
+// testAddress generates a random signing address for tests
+func testAddress(t *testing.T) *signing.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	address := signing.FromAddress("00" + hex.EncodeToString(pub))
+	require.NotNil(t, address)
+	return address
+}
+
+// newTestClient creates a client running on a fake transport
+func newTestClient(t *testing.T) (*Client, *fakeTransport) {
+	transport := newFakeTransport(t)
+	client, err := New(Config{Transport: transport.factory()})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client, transport
+}
+
+func TestNewWithCustomTransport(t *testing.T) {
+	client, transport := newTestClient(t)
+
+	assert.Equal(t, transport.address.String(), client.DID())
+	assert.Same(t, transport, client.Transport())
+	assert.Nil(t, client.Account())
+
+	err := client.Chat().Send(testAddress(t).String(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, 1, transport.sentCount())
+
+	require.NoError(t, client.Storage().StoreString("key", "value"))
+	value, err := client.Storage().LookupString("key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
+
+func TestConfigValidateWithTransport(t *testing.T) {
+	// Storage settings are only required for the default account transport
+	config := Config{Transport: newFakeTransport(t).factory()}
+	assert.NoError(t, config.validate())
+
+	config = Config{}
+	assert.ErrorIs(t, config.validate(), ErrStorageKeyRequired)
+}
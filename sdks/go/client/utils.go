@@ -12,11 +12,12 @@ import (
 func QuickCredentialExchange(requester, responder *Client, credentialType []string, timeout time.Duration) (*CredentialResponse, error) {
 	// Set up a simple handler on the responder
 	responseSent := make(chan bool, 1)
-	responder.Credentials().OnPresentationRequest(func(req *IncomingCredentialRequest) {
+	unsubscribe := responder.Credentials().OnPresentationRequest(func(req *IncomingCredentialRequest) {
 		// For demo purposes, just reject
 		req.Reject()
 		responseSent <- true
 	})
+	defer unsubscribe()
 
 	// Send request
 	details := []*CredentialDetail{